@@ -0,0 +1,118 @@
+package main
+
+// portforward 是NativeSphere port-forward功能的本地配套工具
+// 在工作站上监听本地tcp端口，每个tcp连接都会通过websocket隧道转发到pod的对应端口，使用者无需安装kubectl
+// 使用示例:
+// go run ./cmd/portforward -server ws://127.0.0.1:8081 -token xxx -namespace default -pod mysql-0 -port 3306 -local-port 13306
+
+import (
+	"NativeSphere/config"
+	"flag"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/wonderivan/logger"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// 命令行参数
+var (
+	server    = flag.String("server", "ws://127.0.0.1:8081", "NativeSphere websocket服务地址")
+	token     = flag.String("token", "", "登录获取的jwt token")
+	namespace = flag.String("namespace", "default", "pod所在的namespace")
+	podName   = flag.String("pod", "", "需要转发的pod名称")
+	port      = flag.Int("port", 0, "pod中需要转发的端口")
+	localAddr = flag.String("address", config.PortForwardLocalAddr, "本地监听地址")
+	localPort = flag.Int("local-port", 0, "本地监听端口，默认与pod端口一致")
+)
+
+func main() {
+	flag.Parse()
+	if *podName == "" || *port == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *localPort == 0 {
+		*localPort = *port
+	}
+	listenAddr := net.JoinHostPort(*localAddr, strconv.Itoa(*localPort))
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		logger.Error("监听本地端口失败,错误信息," + err.Error())
+		os.Exit(1)
+	}
+	defer listener.Close()
+	fmt.Printf("Forwarding from %s -> %s/%s:%d\n", listenAddr, *namespace, *podName, *port)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logger.Error("接收本地连接失败,错误信息," + err.Error())
+			continue
+		}
+		go handleConnection(conn)
+	}
+}
+
+// handleConnection 为每个本地tcp连接建立一条websocket隧道，并双向拷贝数据
+func handleConnection(conn net.Conn) {
+	defer conn.Close()
+	query := url.Values{}
+	query.Set("namespace", *namespace)
+	query.Set("podName", *podName)
+	query.Set("port", strconv.Itoa(*port))
+	header := http.Header{}
+	header.Set("Authorization", *token)
+
+	wsConn, resp, err := websocket.DefaultDialer.Dial(*server+"/ws/portforward?"+query.Encode(), header)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			logger.Error("建立websocket隧道失败,错误信息," + err.Error() + ", " + string(body))
+			return
+		}
+		logger.Error("建立websocket隧道失败,错误信息," + err.Error())
+		return
+	}
+	defer wsConn.Close()
+	fmt.Printf("Handling connection for %d\n", *port)
+
+	done := make(chan struct{}, 2)
+	// 本地 -> websocket
+	go func() {
+		buf := make([]byte, config.PortForwardBufferSize)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				if err := wsConn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		done <- struct{}{}
+	}()
+	// websocket -> 本地
+	go func() {
+		for {
+			_, message, err := wsConn.ReadMessage()
+			if err != nil {
+				if closeErr, ok := err.(*websocket.CloseError); ok && closeErr.Text != "" {
+					logger.Error("port-forward隧道关闭," + closeErr.Text)
+				}
+				break
+			}
+			if _, err := conn.Write(message); err != nil {
+				break
+			}
+		}
+		done <- struct{}{}
+	}()
+	<-done
+}
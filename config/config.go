@@ -89,4 +89,101 @@ const (
 	EndOfTransmission   = "\u0004"
 	HandshakeTimeout    = time.Second * 2
 	WebSocketListenAddr = "0.0.0.0:8081"
+	// TerminalShell 终端默认执行的shell
+	TerminalShell = "/bin/bash"
+)
+
+// 终端会话管理配置
+const (
+	// TerminalIdleTimeout 终端会话无输入的最长时间，超过后关闭
+	TerminalIdleTimeout = 30 * time.Minute
+	// TerminalMaxDuration 终端会话的最长持续时间
+	TerminalMaxDuration = 8 * time.Hour
+	// TerminalReapInterval 检查超时会话的间隔
+	TerminalReapInterval = 30 * time.Second
+	// TerminalHeartbeatInterval 服务端向客户端发送心跳的间隔
+	TerminalHeartbeatInterval = 30 * time.Second
+	// TerminalPongWait 等待客户端响应心跳的最长时间，需大于心跳间隔
+	TerminalPongWait = 75 * time.Second
+	// TerminalWriteWait 写入心跳帧的超时时间
+	TerminalWriteWait = 10 * time.Second
+	// TerminalReconnectGrace websocket断开后保留exec stream等待重新连接的时间
+	TerminalReconnectGrace = 2 * time.Minute
+	// TerminalScrollbackSize 会话缓存的最近输出字节数，重新连接时回放
+	TerminalScrollbackSize = 64 * 1024
+)
+
+// TerminalAdminUsers 终端会话管理员，可以查看和强制关闭所有用户的会话，其他用户只能管理自己的会话
+var TerminalAdminUsers = []string{Username}
+
+// 资源watch配置
+const (
+	// WatchMaxSubscriptions 单个websocket连接允许的最大订阅数
+	WatchMaxSubscriptions = 20
+	// WatchHeartbeatInterval 服务端向客户端发送心跳的间隔
+	WatchHeartbeatInterval = 30 * time.Second
+	// WatchPongWait 等待客户端响应心跳的最长时间，需大于心跳间隔
+	WatchPongWait = 75 * time.Second
+	// WatchWriteWait 写入事件的超时时间
+	WatchWriteWait = 10 * time.Second
+)
+
+// 临时调试容器配置
+const (
+	// DebugContainerImage 未指定镜像时使用的默认调试镜像
+	DebugContainerImage = "busybox:1.35"
+	// DebugContainerTimeout 等待调试容器运行的超时时间
+	DebugContainerTimeout = 60 * time.Second
+)
+
+// 权限校验配置
+// 平台使用自身的集群凭证访问k8s，敏感操作(如port-forward、watch)前必须使用SubjectAccessReview校验登录用户的RBAC权限。
+// 平台的登录用户不是k8s中的用户，校验时按AccessReviewUsers映射为k8s用户，未配置映射的用户映射为AccessReviewUserPrefix+用户名，
+// 并附加AccessReviewGroups中的组。部署时必须在集群中为映射后的用户或组绑定RBAC权限，否则这些操作都会返回403，例如:
+// kubectl create clusterrolebinding nativesphere-users --clusterrole=edit --group=nativesphere:users
+// 平台自身使用的集群凭证还需要拥有创建subjectaccessreviews(authorization.k8s.io)的权限
+const (
+	// AccessReviewEnabled 是否开启RBAC权限校验，默认开启，关闭后任何登录用户都能以平台的集群凭证执行上述操作，仅用于开发环境
+	AccessReviewEnabled = true
+	// AccessReviewUserPrefix 未配置映射的平台用户在k8s中的用户名前缀
+	AccessReviewUserPrefix = "nativesphere:"
+)
+
+var (
+	// AccessReviewUsers 平台用户到k8s用户的映射
+	AccessReviewUsers = map[string]string{}
+	// AccessReviewGroups 校验时附加的k8s组
+	AccessReviewGroups = []string{"nativesphere:users"}
+)
+
+// port-forward全局配置
+const (
+	// PortForwardBufferSize websocket与pod端口之间单次转发的缓冲区大小
+	PortForwardBufferSize = 32 * 1024
+	// PortForwardLocalAddr 本地转发模式默认监听地址
+	PortForwardLocalAddr = "127.0.0.1"
+)
+
+// 全局搜索配置
+const (
+	// SearchLimitPerKind 每种资源最多返回的搜索结果数
+	SearchLimitPerKind = 20
+	// SearchTimeout 单次搜索查询所有资源的超时时间
+	SearchTimeout = 10 * time.Second
+)
+
+// 资源apply配置
+const (
+	// ApplyFieldManager server-side apply时使用的field manager名称
+	ApplyFieldManager = "nativesphere"
+	// ApplyMaxDocuments 单次apply允许的最大对象数量
+	ApplyMaxDocuments = 100
+)
+
+// 发布状态配置
+const (
+	// RolloutStatusTimeout 流式获取发布状态的最长时间，超过后结束推送
+	RolloutStatusTimeout = 10 * time.Minute
+	// RolloutPausedAnnotation 暂停StatefulSet、DaemonSet时记录原更新策略的注解，恢复时写回
+	RolloutPausedAnnotation = "nativesphere.io/paused-update-strategy"
 )
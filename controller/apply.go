@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Apply apply
+
+type apply struct{}
+
+// Apply 使用server-side apply应用多文档yaml或json中的所有对象
+func (a *apply) Apply(context *gin.Context) {
+	params := new(struct {
+		Namespace string `json:"namespace"`
+		Content   string `json:"content"`
+		// DryRun 为All时只做服务端校验不持久化
+		DryRun string `json:"dry_run"`
+		Force  bool   `json:"force"`
+	})
+	// POST请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Apply.Apply(params.Namespace, params.Content, params.DryRun, params.Force)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	message := "apply成功!"
+	if data.Failed > 0 {
+		message = "apply完成，部分对象失败"
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    data,
+	})
+}
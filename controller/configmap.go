@@ -18,6 +18,7 @@ func (c *configMap) GetConfigMaps(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败，错误信息, " + err.Error())
@@ -28,7 +29,7 @@ func (c *configMap) GetConfigMaps(context *gin.Context) {
 		return
 	}
 
-	data, err := service.ConfigMap.GetConfigMaps(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.ConfigMap.GetConfigMaps(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -47,6 +48,7 @@ func (c *configMap) GetConfigMapDetail(context *gin.Context) {
 	params := new(struct {
 		ConfigMapName string `form:"configmap_name"`
 		Namespace     string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败, " + err.Error())
@@ -64,9 +66,17 @@ func (c *configMap) GetConfigMapDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取ConfigMap " + params.ConfigMapName + "详情成功!",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -87,6 +97,13 @@ func (c *configMap) UpdateConfigMap(context *gin.Context) {
 	}
 	err := service.ConfigMap.UpdateConfigMap(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -99,6 +116,45 @@ func (c *configMap) UpdateConfigMap(context *gin.Context) {
 	})
 }
 
+// PatchConfigMap 对ConfigMap打补丁，只需提交要修改的字段
+func (c *configMap) PatchConfigMap(context *gin.Context) {
+	params := new(struct {
+		ConfigMapName string `json:"configMapName"`
+		Namespace     string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.ConfigMap.PatchConfigMap(params.ConfigMapName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改ConfigMap成功!",
+		"data":    data,
+	})
+}
+
 // DeleteConfigMap 删除configMap
 func (c *configMap) DeleteConfigMap(context *gin.Context) {
 	params := new(struct {
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Crd crd
+
+type crd struct{}
+
+// GetCrds 获取CRD列表、支持过滤、排序、分页
+func (c *crd) GetCrds(context *gin.Context) {
+	params := new(struct {
+		FilterName string `form:"filter_name"`
+		Page       int    `form:"page"`
+		Limit      int    `form:"limit"`
+		service.SelectParams
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败，错误信息, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Crd.GetCrds(params.FilterName, params.Limit, params.Page, &params.SelectParams)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取CRD列表成功!",
+		"data":    data,
+	})
+}
+
+// GetCrdDetail 获取CRD详情
+func (c *crd) GetCrdDetail(context *gin.Context) {
+	params := new(struct {
+		CrdName string `form:"crd_name"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Crd.GetCrdDetail(params.CrdName)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取CRD " + params.CrdName + "详情成功!",
+		"data":    data,
+	})
+}
+
+// GetCrdInstances 获取CRD实例列表、支持过滤、排序、分页
+func (c *crd) GetCrdInstances(context *gin.Context) {
+	params := new(struct {
+		CrdName    string `form:"crd_name"`
+		Version    string `form:"version"`
+		FilterName string `form:"filter_name"`
+		Namespace  string `form:"namespace"`
+		Page       int    `form:"page"`
+		Limit      int    `form:"limit"`
+		service.SelectParams
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败，错误信息, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Crd.GetCrdInstances(params.CrdName, params.Version, params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取CRD " + params.CrdName + "实例列表成功!",
+		"data":    data,
+	})
+}
+
+// GetCrdInstanceYaml 获取CRD实例的yaml内容
+func (c *crd) GetCrdInstanceYaml(context *gin.Context) {
+	params := new(struct {
+		CrdName   string `form:"crd_name"`
+		Version   string `form:"version"`
+		Name      string `form:"name"`
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Crd.GetCrdInstanceYaml(params.CrdName, params.Version, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取CRD实例 " + params.Name + "详情成功!",
+		"data":    data,
+	})
+}
+
+// UpdateCrdInstance 使用yaml内容更新CRD实例
+func (c *crd) UpdateCrdInstance(context *gin.Context) {
+	params := new(struct {
+		CrdName   string `json:"crd_name"`
+		Namespace string `json:"namespace"`
+		Content   string `json:"content"`
+	})
+	// PUT请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Crd.UpdateCrdInstanceYaml(params.CrdName, params.Namespace, params.Content)
+	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "更新CRD实例成功!",
+		"data":    data,
+	})
+}
+
+// DeleteCrdInstance 删除CRD实例
+func (c *crd) DeleteCrdInstance(context *gin.Context) {
+	params := new(struct {
+		CrdName   string `json:"crd_name"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	})
+	// DELETE请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	err := service.Crd.DeleteCrdInstance(params.CrdName, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "删除CRD实例 " + params.Name + "成功!",
+		"data":    nil,
+	})
+}
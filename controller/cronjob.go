@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var CronJob cronJob
+
+type cronJob struct{}
+
+// GetCronJobs 获取cronJob列表，支持过滤、排序、分页
+func (c *cronJob) GetCronJobs(context *gin.Context) {
+	params := new(struct {
+		FilterName string `form:"filter_name"`
+		Namespace  string `form:"namespace"`
+		Page       int    `form:"page"`
+		Limit      int    `form:"limit"`
+		service.SelectParams
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.CronJob.GetCronJobs(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取cronJob列表成功",
+		"data":    data,
+	})
+}
+
+// GetCronJobDetail 获取cronJob详情
+func (c *cronJob) GetCronJobDetail(context *gin.Context) {
+	params := new(struct {
+		CronJobName string `form:"cronjob_name"`
+		Namespace   string `form:"namespace"`
+		service.DetailFormat
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.CronJob.GetCronJobDetail(params.CronJobName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取cronJob详情成功",
+		"data":    content,
+	})
+}
+
+// CreateCronJob 创建cronJob
+func (c *cronJob) CreateCronJob(context *gin.Context) {
+	var (
+		cronJobCreate = new(service.CronJobCreate)
+		err           error
+	)
+	if err = context.ShouldBindJSON(cronJobCreate); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err = service.CronJob.CreateCronJob(cronJobCreate); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "创建cronJob " + cronJobCreate.Name + "成功",
+		"data":    nil,
+	})
+}
+
+// DeleteCronJob 删除cronJob
+func (c *cronJob) DeleteCronJob(context *gin.Context) {
+	params := new(struct {
+		CronJobName string `json:"cronjob_name"`
+		Namespace   string `json:"namespace"`
+	})
+	// DELETE请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CronJob.DeleteCronJob(params.CronJobName, params.Namespace); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "删除cronJob " + params.CronJobName + "成功",
+		"data":    nil,
+	})
+}
+
+// TriggerCronJob 立即运行一次cronJob
+func (c *cronJob) TriggerCronJob(context *gin.Context) {
+	params := new(struct {
+		CronJobName string `json:"cronjob_name"`
+		Namespace   string `json:"namespace"`
+	})
+	// POST请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	jobName, err := service.CronJob.TriggerCronJob(params.CronJobName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "触发cronJob " + params.CronJobName + "成功",
+		"data":    jobName,
+	})
+}
+
+// SuspendCronJob 暂停cronJob
+func (c *cronJob) SuspendCronJob(context *gin.Context) {
+	c.setSuspend(context, true)
+}
+
+// ResumeCronJob 恢复cronJob
+func (c *cronJob) ResumeCronJob(context *gin.Context) {
+	c.setSuspend(context, false)
+}
+
+// setSuspend 暂停和恢复cronJob共用的处理逻辑
+func (c *cronJob) setSuspend(context *gin.Context, suspend bool) {
+	params := new(struct {
+		CronJobName string `json:"cronjob_name"`
+		Namespace   string `json:"namespace"`
+	})
+	// PUT请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CronJob.SuspendCronJob(params.CronJobName, params.Namespace, suspend); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	action := "恢复"
+	if suspend {
+		action = "暂停"
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": action + "cronJob " + params.CronJobName + "成功",
+		"data":    nil,
+	})
+}
+
+// GetCronJobHistory 获取cronJob的运行历史
+func (c *cronJob) GetCronJobHistory(context *gin.Context) {
+	params := new(struct {
+		CronJobName string `form:"cronjob_name"`
+		Namespace   string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.CronJob.GetCronJobHistory(params.CronJobName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取cronJob运行历史成功",
+		"data":    data,
+	})
+}
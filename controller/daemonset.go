@@ -18,6 +18,7 @@ func (d *daemonSet) GetDaemonSets(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind参数失败,错误信息 " + err.Error())
@@ -28,7 +29,7 @@ func (d *daemonSet) GetDaemonSets(context *gin.Context) {
 		return
 	}
 
-	data, err := service.DaemonSet.GetDaemonSets(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.DaemonSet.GetDaemonSets(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -47,6 +48,7 @@ func (d *daemonSet) GetDaemonSetDetail(context *gin.Context) {
 	params := new(struct {
 		DaemonSetName string `form:"daemonset_name"`
 		Namespace     string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败, 错误信息 " + err.Error())
@@ -64,9 +66,44 @@ func (d *daemonSet) GetDaemonSetDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取damonSet " + params.DaemonSetName + "详细成功!",
-		"data":    data,
+		"data":    content,
+	})
+}
+
+// CreateDaemonSet 创建daemonSet
+func (d *daemonSet) CreateDaemonSet(context *gin.Context) {
+	var (
+		daemonSetCreate = new(service.DaemonSetCreate)
+		err             error
+	)
+	if err = context.ShouldBindJSON(daemonSetCreate); err != nil {
+		logger.Error("Bind请求参数失败，" + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err = service.DaemonSet.CreateDaemonSets(daemonSetCreate); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "创建daemonSet" + daemonSetCreate.Name + "成功",
+		"data":    nil,
 	})
 }
 
@@ -100,6 +137,35 @@ func (d *daemonSet) DeleteDaemonSet(context *gin.Context) {
 	})
 }
 
+// RestartDaemonSet 重启DaemonSet
+func (d *daemonSet) RestartDaemonSet(context *gin.Context) {
+	params := new(struct {
+		DaemonSetName string `json:"daemonSet_name"`
+		Namespace     string `json:"namespace"`
+	})
+	//PUT请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	err := service.DaemonSet.RestartDaemonSet(params.DaemonSetName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "重启DaemonSet成功",
+		"data":    nil,
+	})
+}
+
 // UpdateDaemonSet 更新daemonSet
 func (d *daemonSet) UpdateDaemonSet(context *gin.Context) {
 	params := new(struct {
@@ -118,6 +184,13 @@ func (d *daemonSet) UpdateDaemonSet(context *gin.Context) {
 
 	err := service.DaemonSet.UpdateDaemonSet(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -129,3 +202,42 @@ func (d *daemonSet) UpdateDaemonSet(context *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// PatchDaemonSet 对DaemonSet打补丁，只需提交要修改的字段
+func (d *daemonSet) PatchDaemonSet(context *gin.Context) {
+	params := new(struct {
+		DaemonSetName string `json:"daemonSet_name"`
+		Namespace     string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.DaemonSet.PatchDaemonSet(params.DaemonSetName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改DaemonSet成功!",
+		"data":    data,
+	})
+}
@@ -20,6 +20,9 @@ func (d *deployment) GetDeployments(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		// View 列表视图，summary时返回精简字段，默认返回完整对象
+		View string `form:"view"`
+		service.SelectParams
 	})
 
 	if err := context.Bind(params); err != nil {
@@ -30,7 +33,20 @@ func (d *deployment) GetDeployments(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.Deployment.GetDeployments(params.FilterName, params.Namespace, params.Limit, params.Page)
+	if err := service.CheckView(params.View); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	var data interface{}
+	var err error
+	if params.View == service.ViewSummary {
+		data, err = service.Deployment.GetDeploymentSummaries(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	} else {
+		data, err = service.Deployment.GetDeployments(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	}
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -49,6 +65,7 @@ func (d *deployment) GetDeploymentDetail(context *gin.Context) {
 	params := new(struct {
 		DeploymentName string `form:"deployment_name"`
 		Namespace      string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
@@ -66,9 +83,17 @@ func (d *deployment) GetDeploymentDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取namespace " + params.Namespace + "成功",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -122,9 +147,42 @@ func (d *deployment) ScaleDeployment(context *gin.Context) {
 		})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "设置deployment" + params.DeploymentName + "副本数至" + strconv.Itoa(params.ScaleNum) + "成功",
 		"data":    fmt.Sprintf("最新副本数：%d\n", data),
+	}
+	// deployment由hpa管理时，手动设置的副本数会被覆盖，返回提示
+	if warning := service.HPA.ScaleWarning("Deployment", params.DeploymentName, params.Namespace, data); warning != "" {
+		response["warning"] = warning
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// GetDeploymentHPA 获取管理deployment副本数的hpa，没有hpa时data为nil
+func (d *deployment) GetDeploymentHPA(context *gin.Context) {
+	params := new(struct {
+		DeploymentName string `form:"deployment_name"`
+		Namespace      string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.HPA.GetTargetHPA("Deployment", params.DeploymentName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取deployment " + params.DeploymentName + "的hpa成功",
+		"data":    data,
 	})
 }
 
@@ -203,6 +261,13 @@ func (d *deployment) UpdateDeployment(context *gin.Context) {
 	}
 	err := service.Deployment.UpdateDeployment(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -215,11 +280,50 @@ func (d *deployment) UpdateDeployment(context *gin.Context) {
 	})
 }
 
-// GetDeployReplicaSets 获取deployment的历史版本信息
-func (d *deployment) GetDeployReplicaSets(context *gin.Context) {
+// PatchDeployment 对Deployment打补丁，只需提交要修改的字段
+func (d *deployment) PatchDeployment(context *gin.Context) {
 	params := new(struct {
 		DeploymentName string `json:"deployment_name"`
 		Namespace      string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Deployment.PatchDeployment(params.DeploymentName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Deployment成功!",
+		"data":    data,
+	})
+}
+
+// GetDeploymentHistory 获取deployment的发布历史
+func (d *deployment) GetDeploymentHistory(context *gin.Context) {
+	params := new(struct {
+		DeploymentName string `form:"deployment_name"`
+		Namespace      string `form:"namespace"`
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败,错误信息, " + err.Error())
@@ -229,7 +333,7 @@ func (d *deployment) GetDeployReplicaSets(context *gin.Context) {
 		})
 		return
 	}
-	err := service.Deployment.GetDeployReplicaSets(params.DeploymentName, params.Namespace)
+	data, err := service.Deployment.GetDeploymentHistory(params.DeploymentName, params.Namespace)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -239,7 +343,68 @@ func (d *deployment) GetDeployReplicaSets(context *gin.Context) {
 	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取deployment " + params.DeploymentName + "历史版本信息成功!",
-		"data":    nil,
+		"data":    data,
+	})
+}
+
+// GetRevisionDiff 对比deployment两个版本的pod模板
+func (d *deployment) GetRevisionDiff(context *gin.Context) {
+	params := new(struct {
+		DeploymentName string `form:"deployment_name"`
+		Namespace      string `form:"namespace"`
+		From           int64  `form:"from"`
+		To             int64  `form:"to"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Deployment.GetRevisionDiff(params.DeploymentName, params.Namespace, params.From, params.To)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取deployment " + params.DeploymentName + "版本对比成功!",
+		"data":    data,
+	})
+}
+
+// RollbackDeployment 回滚deployment到指定版本
+func (d *deployment) RollbackDeployment(context *gin.Context) {
+	params := new(struct {
+		DeploymentName string `json:"deployment_name"`
+		Namespace      string `json:"namespace"`
+		// Revision 回滚到的版本号，为0时回滚到上一个版本
+		Revision int64 `json:"revision"`
+	})
+	//PUT请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	revision, err := service.Deployment.RollbackDeployment(params.DeploymentName, params.Namespace, params.Revision)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "回滚deployment " + params.DeploymentName + "到版本" + strconv.FormatInt(revision, 10) + "成功!",
+		"data":    revision,
 	})
 }
 
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Describe describe
+
+type describe struct{}
+
+// Describe 获取资源的describe内容，同时返回结构化分段和文本
+func (d *describe) Describe(context *gin.Context) {
+	params := new(struct {
+		// Kind 资源类型，可选值为pod、deployment、statefulset、daemonset、service、ingress、node、pv、pvc
+		Kind      string `form:"kind"`
+		Name      string `form:"name"`
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Describe.Describe(params.Kind, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取" + params.Kind + " " + params.Name + "的describe成功!",
+		"data":    data,
+	})
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Diff diff
+
+type diff struct{}
+
+// Preview 预览更新的内容，返回dryRun结果与当前对象的unified diff
+func (d *diff) Preview(context *gin.Context) {
+	params := new(struct {
+		// ResourceType 对象中没有apiVersion和kind时用于确定资源类型
+		service.ResourceType
+		Namespace string `json:"namespace"`
+		Content   string `json:"content"`
+	})
+	// POST请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Diff.Preview(&params.ResourceType, params.Namespace, params.Content)
+	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取diff预览成功!",
+		"data":    data,
+	})
+}
@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var HPA hpa
+
+type hpa struct{}
+
+// GetHPAs 获取hpa列表，支持过滤、排序、分页
+func (h *hpa) GetHPAs(context *gin.Context) {
+	params := new(struct {
+		FilterName string `form:"filter_name"`
+		Namespace  string `form:"namespace"`
+		Page       int    `form:"page"`
+		Limit      int    `form:"limit"`
+		service.SelectParams
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.HPA.GetHPAs(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取hpa列表成功",
+		"data":    data,
+	})
+}
+
+// GetHPADetail 获取hpa详情
+func (h *hpa) GetHPADetail(context *gin.Context) {
+	params := new(struct {
+		HPAName   string `form:"hpa_name"`
+		Namespace string `form:"namespace"`
+		service.DetailFormat
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.HPA.GetHPADetail(params.HPAName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取hpa详情成功",
+		"data":    content,
+	})
+}
+
+// GetHPAStatus 获取hpa的指标当前值与目标值以及最近的扩缩容事件
+func (h *hpa) GetHPAStatus(context *gin.Context) {
+	params := new(struct {
+		HPAName   string `form:"hpa_name"`
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.HPA.GetHPAStatus(params.HPAName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取hpa状态成功",
+		"data":    data,
+	})
+}
+
+// CreateHPA 创建hpa
+func (h *hpa) CreateHPA(context *gin.Context) {
+	var (
+		hpaCreate = new(service.HPACreate)
+		err       error
+	)
+	if err = context.ShouldBindJSON(hpaCreate); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err = service.HPA.CreateHPA(hpaCreate); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "创建hpa " + hpaCreate.Name + "成功",
+		"data":    nil,
+	})
+}
+
+// UpdateHPA 更新hpa
+func (h *hpa) UpdateHPA(context *gin.Context) {
+	params := new(struct {
+		Namespace string `json:"namespace"`
+		Content   string `json:"content"`
+	})
+	// PUT请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.HPA.UpdateHPA(params.Namespace, params.Content); err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "更新hpa成功",
+		"data":    nil,
+	})
+}
+
+// DeleteHPA 删除hpa
+func (h *hpa) DeleteHPA(context *gin.Context) {
+	params := new(struct {
+		HPAName   string `json:"hpa_name"`
+		Namespace string `json:"namespace"`
+	})
+	// DELETE请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.HPA.DeleteHPA(params.HPAName, params.Namespace); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "删除hpa " + params.HPAName + "成功",
+		"data":    nil,
+	})
+}
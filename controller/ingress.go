@@ -19,6 +19,7 @@ func (i *ingress) GetIngresses(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind参数失败,错误信息," + err.Error())
@@ -28,7 +29,7 @@ func (i *ingress) GetIngresses(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.Ingress.GetIngresses(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.Ingress.GetIngresses(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -47,6 +48,7 @@ func (i *ingress) GetIngressDetail(context *gin.Context) {
 	params := new(struct {
 		IngressName string `form:"ingressName"`
 		Namespace   string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败,错误信息," + err.Error())
@@ -64,9 +66,17 @@ func (i *ingress) GetIngressDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取ingress " + params.IngressName + "详情成功!",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -143,6 +153,13 @@ func (i *ingress) UpdateIngress(context *gin.Context) {
 	}
 	err := service.Ingress.UpdateIngress(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -154,3 +171,42 @@ func (i *ingress) UpdateIngress(context *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// PatchIngress 对Ingress打补丁，只需提交要修改的字段
+func (i *ingress) PatchIngress(context *gin.Context) {
+	params := new(struct {
+		IngressName string `json:"ingressName"`
+		Namespace   string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Ingress.PatchIngress(params.IngressName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Ingress成功!",
+		"data":    data,
+	})
+}
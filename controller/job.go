@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Job job
+
+type job struct{}
+
+// GetJobs 获取job列表，支持过滤、排序、分页
+func (j *job) GetJobs(context *gin.Context) {
+	params := new(struct {
+		FilterName string `form:"filter_name"`
+		Namespace  string `form:"namespace"`
+		Page       int    `form:"page"`
+		Limit      int    `form:"limit"`
+		service.SelectParams
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Job.GetJobs(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取job列表成功",
+		"data":    data,
+	})
+}
+
+// GetJobDetail 获取job详情
+func (j *job) GetJobDetail(context *gin.Context) {
+	params := new(struct {
+		JobName   string `form:"job_name"`
+		Namespace string `form:"namespace"`
+		service.DetailFormat
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Job.GetJobDetail(params.JobName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取job详情成功",
+		"data":    content,
+	})
+}
+
+// CreateJob 创建job
+func (j *job) CreateJob(context *gin.Context) {
+	var (
+		jobCreate = new(service.JobCreate)
+		err       error
+	)
+	if err = context.ShouldBindJSON(jobCreate); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err = service.Job.CreateJob(jobCreate); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "创建job " + jobCreate.Name + "成功",
+		"data":    nil,
+	})
+}
+
+// DeleteJob 删除job
+func (j *job) DeleteJob(context *gin.Context) {
+	params := new(struct {
+		JobName   string `json:"job_name"`
+		Namespace string `json:"namespace"`
+	})
+	// DELETE请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.Job.DeleteJob(params.JobName, params.Namespace); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "删除job " + params.JobName + "成功",
+		"data":    nil,
+	})
+}
+
+// CleanFinishedJobs 清理已结束的job，默认跳过cronJob创建的job
+func (j *job) CleanFinishedJobs(context *gin.Context) {
+	params := new(struct {
+		Namespace      string `json:"namespace"`
+		Status         string `json:"status"`
+		OlderThan      int64  `json:"older_than"`
+		IncludeCronJob bool   `json:"include_cronjob"`
+	})
+	// DELETE请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if params.Namespace == "" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "namespace不能为空",
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Job.CleanFinishedJobs(params.Namespace, params.Status, params.OlderThan, params.IncludeCronJob)
+	if err != nil {
+		// 删除中途失败时同时返回已删除的job
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    data,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "清理已结束的job成功",
+		"data":    data,
+	})
+}
@@ -18,6 +18,7 @@ func (n *namespace) GetNamespaces(context *gin.Context) {
 		FilterName string `form:"filter_name"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败 " + err.Error())
@@ -27,7 +28,7 @@ func (n *namespace) GetNamespaces(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.Namespace.GetNamespaces(params.FilterName, params.Limit, params.Page)
+	data, err := service.Namespace.GetNamespaces(params.FilterName, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusNonAuthoritativeInfo, gin.H{
 			"message": err.Error(),
@@ -45,6 +46,7 @@ func (n *namespace) GetNamespaces(context *gin.Context) {
 func (n *namespace) GetNamespaceDetail(context *gin.Context) {
 	params := new(struct {
 		NamespaceName string `form:"namespace_name"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error(errors.New("Bind请求参数失败，错误信息 " + err.Error()))
@@ -62,8 +64,54 @@ func (n *namespace) GetNamespaceDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取namespace " + params.NamespaceName + "详情成功!",
+		"data":    content,
+	})
+}
+
+// PatchNamespace 对Namespace打补丁，只需提交要修改的字段
+func (n *namespace) PatchNamespace(context *gin.Context) {
+	params := new(struct {
+		NamespaceName string `json:"namespace_name"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Namespace.PatchNamespace(params.NamespaceName, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Namespace成功!",
 		"data":    data,
 	})
 }
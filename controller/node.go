@@ -17,6 +17,7 @@ func (n *node) GetNodes(context *gin.Context) {
 		FilterName string `form:"filter_name"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败, " + err.Error())
@@ -27,7 +28,7 @@ func (n *node) GetNodes(context *gin.Context) {
 		return
 	}
 
-	data, err := service.Node.GetNodes(params.FilterName, params.Limit, params.Page)
+	data, err := service.Node.GetNodes(params.FilterName, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"msg":  err.Error(),
@@ -46,6 +47,7 @@ func (n *node) GetNodes(context *gin.Context) {
 func (n *node) GetNodeDetail(context *gin.Context) {
 	params := new(struct {
 		NodeName string `form:"node_name"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败, " + err.Error())
@@ -64,9 +66,55 @@ func (n *node) GetNodeDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取Node详情成功",
+		"data":    content,
+	})
+}
+
+// PatchNode 对Node打补丁，只需提交要修改的字段
+func (n *node) PatchNode(context *gin.Context) {
+	params := new(struct {
+		NodeName string `json:"node_name"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Node.PatchNode(params.NodeName, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Node成功!",
 		"data":    data,
 	})
 }
@@ -25,6 +25,9 @@ func (p *pod) GetPods(ctx *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Limit      int    `form:"limit"`
 		Page       int    `form:"page"`
+		// View 列表视图，summary时返回精简字段，默认返回完整对象
+		View string `form:"view"`
+		service.SelectParams
 	})
 
 	// form格式使用Bind方法，json格式使用ShouldBindJSON方法
@@ -37,12 +40,26 @@ func (p *pod) GetPods(ctx *gin.Context) {
 		// 如果绑定失败，则不往下执行
 		return
 	}
-	data, err := service.Pod.GetPods(params.FilterName, params.Namespace, params.Limit, params.Page)
+	if err := service.CheckView(params.View); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	var data interface{}
+	var err error
+	if params.View == service.ViewSummary {
+		data, err = service.Pod.GetPodSummaries(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	} else {
+		data, err = service.Pod.GetPods(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	}
 	if err != nil {
-		logger.Error(http.StatusInternalServerError, gin.H{
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"msg":  "获取namespace" + params.Namespace + "pod列表失败, 错误信息" + err.Error(),
 			"data": nil,
 		})
+		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{
 		"msg":  "获取namespace" + params.Namespace + "pod列表成功",
@@ -57,6 +74,7 @@ func (p *pod) GetPodDetail(ctx *gin.Context) {
 	params := new(struct {
 		PodName   string `form:"pod_name"`
 		Namespace string `form:"namespace"`
+		service.DetailFormat
 	})
 	// form格式使用Bind方法，json格式使用ShouldBindJSON方法
 	if err := ctx.Bind(params); err != nil {
@@ -75,9 +93,17 @@ func (p *pod) GetPodDetail(ctx *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
 	ctx.JSON(http.StatusOK, gin.H{
 		"msg":  "获取pod详情成功",
-		"data": data,
+		"data": content,
 	})
 }
 
@@ -129,6 +155,13 @@ func (p *pod) UpdatePod(ctx *gin.Context) {
 	}
 	err := service.Pod.UpdatePod(params.PodName, params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"msg":  err.Error(),
+				"data": conflict,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"msg":  err.Error(),
 			"data": nil,
@@ -141,6 +174,45 @@ func (p *pod) UpdatePod(ctx *gin.Context) {
 	})
 }
 
+// PatchPod 对Pod打补丁，只需提交要修改的字段
+func (p *pod) PatchPod(ctx *gin.Context) {
+	params := new(struct {
+		PodName   string `json:"pod_name"`
+		Namespace string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	data, err := service.Pod.PatchPod(params.PodName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"msg":  "修改Pod成功!",
+		"data": data,
+	})
+}
+
 // GetPodContainer 获取pod容器
 func (p *pod) GetPodContainer(ctx *gin.Context) {
 	params := new(struct {
@@ -170,6 +242,35 @@ func (p *pod) GetPodContainer(ctx *gin.Context) {
 	})
 }
 
+// CreateDebugContainer 向pod注入临时调试容器
+func (p *pod) CreateDebugContainer(ctx *gin.Context) {
+	var (
+		podDebugCreate = new(service.PodDebugCreate)
+		err            error
+	)
+	//POST请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err = ctx.ShouldBindJSON(podDebugCreate); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	data, err := service.Pod.CreateDebugContainer(podDebugCreate)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"msg":  "pod" + podDebugCreate.PodName + "注入调试容器" + data + "成功",
+		"data": data,
+	})
+}
+
 // GetPodLog 获取pod中容器日志
 func (p *pod) GetPodLog(ctx *gin.Context) {
 	params := new(struct {
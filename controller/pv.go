@@ -17,6 +17,7 @@ func (p *pv) GetPvs(context *gin.Context) {
 		FilterName string `form:"filter_name"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind参数失败,错误信息， " + err.Error())
@@ -26,7 +27,7 @@ func (p *pv) GetPvs(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.Pv.GetPvs(params.FilterName, params.Limit, params.Page)
+	data, err := service.Pv.GetPvs(params.FilterName, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -44,6 +45,7 @@ func (p *pv) GetPvs(context *gin.Context) {
 func (p *pv) GetPvDetail(context *gin.Context) {
 	params := new(struct {
 		PvName string `form:"pv_name"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败，错误信息 " + err.Error())
@@ -62,8 +64,54 @@ func (p *pv) GetPvDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取pv " + params.PvName + "详情成功!",
+		"data":    content,
+	})
+}
+
+// PatchPv 对Pv打补丁，只需提交要修改的字段
+func (p *pv) PatchPv(context *gin.Context) {
+	params := new(struct {
+		PvName string `json:"pv_name"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Pv.PatchPv(params.PvName, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Pv成功!",
 		"data":    data,
 	})
 }
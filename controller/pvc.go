@@ -18,6 +18,7 @@ func (p *pvc) GetPvcs(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败, " + err.Error())
@@ -28,7 +29,7 @@ func (p *pvc) GetPvcs(context *gin.Context) {
 		return
 	}
 
-	data, err := service.Pvc.GetPvcs(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.Pvc.GetPvcs(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -48,6 +49,7 @@ func (p *pvc) GetPvcDetail(context *gin.Context) {
 	params := new(struct {
 		PvcName   string `form:"pvc_name"`
 		Namespace string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败, " + err.Error())
@@ -66,10 +68,18 @@ func (p *pvc) GetPvcDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取Pvc详情成功",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -121,6 +131,13 @@ func (p *pvc) UpdatePvc(context *gin.Context) {
 
 	err := service.Pvc.UpdatePvc(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -132,3 +149,42 @@ func (p *pvc) UpdatePvc(context *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// PatchPvc 对Pvc打补丁，只需提交要修改的字段
+func (p *pvc) PatchPvc(context *gin.Context) {
+	params := new(struct {
+		PvcName   string `json:"pvc_name"`
+		Namespace string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Pvc.PatchPvc(params.PvcName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Pvc成功!",
+		"data":    data,
+	})
+}
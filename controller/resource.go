@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+// Resource 通用资源接口，可操作任意GroupVersionResource的资源
+var Resource resource
+
+type resource struct{}
+
+// GetApiResources 获取集群支持的资源类型列表
+func (r *resource) GetApiResources(context *gin.Context) {
+	data, err := service.Resource.GetApiResources()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取资源类型列表成功!",
+		"data":    data,
+	})
+}
+
+// GetResources 获取任意资源列表、支持过滤、排序、分页
+func (r *resource) GetResources(context *gin.Context) {
+	params := new(struct {
+		service.ResourceType
+		FilterName string `form:"filter_name"`
+		Namespace  string `form:"namespace"`
+		Page       int    `form:"page"`
+		Limit      int    `form:"limit"`
+		// View 列表视图，table时返回apiserver生成的Table列和行，默认返回完整对象
+		View string `form:"view"`
+		service.SelectParams
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败，错误信息, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	var data interface{}
+	var err error
+	switch params.View {
+	case "", service.ViewFull:
+		data, err = service.Resource.GetResources(&params.ResourceType, params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	case service.ViewTable:
+		data, err = service.Resource.GetResourceTable(&params.ResourceType, params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
+	default:
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "不支持的view参数 " + params.View + ",可选值为full、table",
+			"data":    nil,
+		})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取" + params.Resource + "列表成功!",
+		"data":    data,
+	})
+}
+
+// GetResourceDetail 获取任意资源详情
+func (r *resource) GetResourceDetail(context *gin.Context) {
+	params := new(struct {
+		service.ResourceType
+		Name      string `form:"name"`
+		Namespace string `form:"namespace"`
+		service.DetailFormat
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Resource.GetResourceDetail(&params.ResourceType, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取" + params.Resource + " " + params.Name + "详情成功!",
+		"data":    content,
+	})
+}
+
+// CreateResource 创建任意资源
+func (r *resource) CreateResource(context *gin.Context) {
+	params := new(struct {
+		service.ResourceType
+		Namespace string `json:"namespace"`
+		Content   string `json:"content"`
+	})
+	// POST请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Resource.CreateResource(&params.ResourceType, params.Namespace, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "创建" + params.Resource + "成功!",
+		"data":    data,
+	})
+}
+
+// UpdateResource 更新任意资源
+func (r *resource) UpdateResource(context *gin.Context) {
+	params := new(struct {
+		service.ResourceType
+		Namespace string `json:"namespace"`
+		Content   string `json:"content"`
+	})
+	// PUT请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Resource.UpdateResource(&params.ResourceType, params.Namespace, params.Content)
+	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "更新" + params.Resource + "成功!",
+		"data":    data,
+	})
+}
+
+// PatchResource 对任意资源打补丁
+func (r *resource) PatchResource(context *gin.Context) {
+	params := new(struct {
+		service.ResourceType
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	// 校验补丁内容，以及资源类型是否支持该补丁类型(CRD不支持strategic)
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.Resource.CheckPatchType(&params.ResourceType, params.PatchType); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Resource.PatchResource(&params.ResourceType, params.Name, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改" + params.Resource + " " + params.Name + "成功!",
+		"data":    data,
+	})
+}
+
+// DeleteResource 删除任意资源
+func (r *resource) DeleteResource(context *gin.Context) {
+	params := new(struct {
+		service.ResourceType
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	})
+	// DELETE请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	err := service.Resource.DeleteResource(&params.ResourceType, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "删除" + params.Resource + " " + params.Name + "成功!",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Rollout rollout
+
+type rollout struct{}
+
+// GetRolloutStatus 获取deployment、statefulset、daemonset的发布状态
+func (r *rollout) GetRolloutStatus(context *gin.Context) {
+	params := new(struct {
+		Kind      string `form:"kind"`
+		Name      string `form:"name"`
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Rollout.GetStatus(params.Kind, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取发布状态成功!",
+		"data":    data,
+	})
+}
+
+// WatchRolloutStatus 以server-sent events的方式推送发布状态，每次状态变化推送一个status事件，
+// 发布完成、失败或暂停后结束，出错时推送error事件
+func (r *rollout) WatchRolloutStatus(context *gin.Context) {
+	params := new(struct {
+		Kind      string `form:"kind"`
+		Name      string `form:"name"`
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.Header("Content-Type", "text/event-stream")
+	context.Header("Cache-Control", "no-cache")
+	context.Header("Connection", "keep-alive")
+	err := service.Rollout.WatchStatus(context.Request.Context(), params.Kind, params.Name, params.Namespace,
+		func(status *service.RolloutStatus) error {
+			context.SSEvent("status", status)
+			context.Writer.Flush()
+			return context.Request.Context().Err()
+		})
+	if err != nil {
+		context.SSEvent("error", err.Error())
+		context.Writer.Flush()
+	}
+}
+
+// PauseRollout 暂停发布
+func (r *rollout) PauseRollout(context *gin.Context) {
+	params := new(struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	})
+	// PUT请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.Rollout.Pause(params.Kind, params.Name, params.Namespace); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "暂停" + params.Kind + " " + params.Name + "成功!",
+		"data":    nil,
+	})
+}
+
+// ResumeRollout 恢复发布
+func (r *rollout) ResumeRollout(context *gin.Context) {
+	params := new(struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	})
+	// PUT请求，绑定参数方法为context.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.Rollout.Resume(params.Kind, params.Name, params.Namespace); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "恢复" + params.Kind + " " + params.Name + "成功!",
+		"data":    nil,
+	})
+}
@@ -23,18 +23,45 @@ func (r *router) InitApiRouter(router *gin.Engine) {
 		POST("/api/v1/k8s/workflow/create", Workflow.Create).
 		DELETE("/api/v1/k8s/workflow/del", Workflow.DelById).
 		GET("/api/v1/k8s/testapi", TestApi.TestAPI).
+		/* 全局搜索路由 */
+		GET("/api/v1/k8s/search", Search.Search).
+		/* describe路由 */
+		GET("/api/v1/k8s/describe", Describe.Describe).
+		/* apply路由，支持多文档yaml和server-side apply */
+		POST("/api/v1/k8s/apply", Apply.Apply).
+		/* diff预览路由，dryRun后与当前对象对比 */
+		POST("/api/v1/k8s/diff", Diff.Preview).
+		/* 发布状态路由，支持deployment、statefulset、daemonset */
+		GET("/api/v1/k8s/rollout/status", Rollout.GetRolloutStatus).
+		GET("/api/v1/k8s/rollout/status/stream", Rollout.WatchRolloutStatus).
+		PUT("/api/v1/k8s/rollout/pause", Rollout.PauseRollout).
+		PUT("/api/v1/k8s/rollout/resume", Rollout.ResumeRollout).
+		/* owner关系树路由，支持deployment、statefulset、daemonset、replicaset、job、cronjob、pod */
+		GET("/api/v1/k8s/workload/tree", Tree.GetWorkloadTree).
 		/* POD相关路由 */
 		GET("/api/v1/k8s/pods", Pod.GetPods).
 		GET("/api/v1/k8s/pod/detail", Pod.GetPodDetail).
+		PATCH("/api/v1/k8s/pod/patch", Pod.PatchPod).
 		DELETE("/api/v1/k8s/pod/delete", Pod.DeletePod).
 		PUT("/api/v1/k8s/pod/update", Pod.UpdatePod).
 		GET("/api/v1/k8s/pod/container", Pod.GetPodContainer).
+		POST("/api/v1/k8s/pod/debug", Pod.CreateDebugContainer).
 		GET("/api/v1/k8s/pod/log", Pod.GetPodLog).
 		GET("/api/v1/k8s/pod/numnp", Pod.GetPodNumPerNp).
+		/* 终端会话相关路由 */
+		GET("/api/v1/k8s/terminal/sessions", Terminal.GetSessions).
+		DELETE("/api/v1/k8s/terminal/session/del", Terminal.KillSession).
+		POST("/api/v1/k8s/terminal/session/share", Terminal.ShareSession).
+		GET("/api/v1/k8s/terminal/session/viewers", Terminal.GetViewers).
+		DELETE("/api/v1/k8s/terminal/session/viewer/del", Terminal.RevokeViewer).
 		/* Deployment相关路由 */
 		GET("/api/v1/k8s/deployments", Deployment.GetDeployments).
-		GET("/api/v1/k8s/deployment/rs", Deployment.GetDeployReplicaSets).
+		GET("/api/v1/k8s/deployment/history", Deployment.GetDeploymentHistory).
+		GET("/api/v1/k8s/deployment/history/diff", Deployment.GetRevisionDiff).
+		GET("/api/v1/k8s/deployment/hpa", Deployment.GetDeploymentHPA).
+		PUT("/api/v1/k8s/deployment/rollback", Deployment.RollbackDeployment).
 		GET("/api/v1/k8s/deployment/detail", Deployment.GetDeploymentDetail).
+		PATCH("/api/v1/k8s/deployment/patch", Deployment.PatchDeployment).
 		PUT("/api/v1/k8s/deployment/scale", Deployment.ScaleDeployment).
 		DELETE("/api/v1/k8s/deployment/del", Deployment.DeleteDeployment).
 		PUT("/api/v1/k8s/deployment/restart", Deployment.RestartDeployment).
@@ -44,50 +71,102 @@ func (r *router) InitApiRouter(router *gin.Engine) {
 		/* DaemonSet相关路由 */
 		GET("/api/v1/k8s/daemonsets", DaemonSet.GetDaemonSets).
 		GET("/api/v1/k8s/daemonset/detail", DaemonSet.GetDaemonSetDetail).
+		PATCH("/api/v1/k8s/daemonset/patch", DaemonSet.PatchDaemonSet).
+		POST("/api/v1/k8s/daemonset/create", DaemonSet.CreateDaemonSet).
 		DELETE("/api/v1/k8s/daemonset/del", DaemonSet.DeleteDaemonSet).
 		PUT("/api/v1/k8s/daemonset/update", DaemonSet.UpdateDaemonSet).
+		PUT("/api/v1/k8s/daemonset/restart", DaemonSet.RestartDaemonSet).
 		/* statefulSet相关路由*/
 		GET("/api/v1/k8s/statefulsets", StatefulSet.GetStatefulSets).
 		GET("/api/v1/k8s/statefulset/detail", StatefulSet.GetStatefulSetDetail).
+		PATCH("/api/v1/k8s/statefulset/patch", StatefulSet.PatchStatefulSet).
 		DELETE("/api/v1/k8s/statefulset/del", StatefulSet.DeleteStatefulSet).
 		PUT("/api/v1/k8s/statefulset/update", StatefulSet.UpdateStatefulSet).
+		PUT("/api/v1/k8s/statefulset/restart", StatefulSet.RestartStatefulSet).
+		POST("/api/v1/k8s/statefulset/create", StatefulSet.CreateStatefulSet).
+		PUT("/api/v1/k8s/statefulset/scale", StatefulSet.ScaleStatefulSet).
+		/* hpa相关路由 */
+		GET("/api/v1/k8s/hpas", HPA.GetHPAs).
+		GET("/api/v1/k8s/hpa/detail", HPA.GetHPADetail).
+		GET("/api/v1/k8s/hpa/status", HPA.GetHPAStatus).
+		POST("/api/v1/k8s/hpa/create", HPA.CreateHPA).
+		PUT("/api/v1/k8s/hpa/update", HPA.UpdateHPA).
+		DELETE("/api/v1/k8s/hpa/del", HPA.DeleteHPA).
+		/* job相关路由 */
+		GET("/api/v1/k8s/jobs", Job.GetJobs).
+		GET("/api/v1/k8s/job/detail", Job.GetJobDetail).
+		POST("/api/v1/k8s/job/create", Job.CreateJob).
+		DELETE("/api/v1/k8s/job/del", Job.DeleteJob).
+		DELETE("/api/v1/k8s/job/clean", Job.CleanFinishedJobs).
+		/* cronJob相关路由 */
+		GET("/api/v1/k8s/cronjobs", CronJob.GetCronJobs).
+		GET("/api/v1/k8s/cronjob/detail", CronJob.GetCronJobDetail).
+		GET("/api/v1/k8s/cronjob/history", CronJob.GetCronJobHistory).
+		POST("/api/v1/k8s/cronjob/create", CronJob.CreateCronJob).
+		POST("/api/v1/k8s/cronjob/trigger", CronJob.TriggerCronJob).
+		PUT("/api/v1/k8s/cronjob/suspend", CronJob.SuspendCronJob).
+		PUT("/api/v1/k8s/cronjob/resume", CronJob.ResumeCronJob).
+		DELETE("/api/v1/k8s/cronjob/del", CronJob.DeleteCronJob).
 		/* service相关路由 */
 		GET("/api/v1/k8s/services", Servicev1.GetServices).
 		GET("/api/v1/k8s/service/detail", Servicev1.GetServiceDetail).
+		PATCH("/api/v1/k8s/service/patch", Servicev1.PatchService).
 		DELETE("/api/v1/k8s/service/del", Servicev1.DeleteService).
 		PUT("/api/v1/k8s/service/update", Servicev1.UpdateService).
 		POST("/api/v1/k8s/service/create", Servicev1.CreateService).
 		/* ingress相关路由 */
 		GET("/api/v1/k8s/ingresses", Ingress.GetIngresses).
 		GET("/api/v1/k8s/ingress/detail", Ingress.GetIngressDetail).
+		PATCH("/api/v1/k8s/ingress/patch", Ingress.PatchIngress).
 		DELETE("/api/v1/k8s/ingress/del", Ingress.DeleteIngress).
 		PUT("/api/v1/k8s/ingress/update", Ingress.UpdateIngress).
 		POST("/api/v1/k8s/ingress/create", Ingress.CreateIngress).
 		/* namespace相关 */
 		GET("/api/v1/k8s/namespaces", Namespace.GetNamespaces).
 		GET("/api/v1/k8s/namespace/detail", Namespace.GetNamespaceDetail).
+		PATCH("/api/v1/k8s/namespace/patch", Namespace.PatchNamespace).
 		DELETE("/api/v1/k8s/namespace/del", Namespace.DeleteNamespace).
 		POST("/api/v1/k8s/namespace/create", Namespace.CreateNamespace).
 		/* PV相关路由 */
 		GET("/api/v1/k8s/pvs", Pv.GetPvs).
 		GET("/api/v1/k8s/pv/detail", Pv.GetPvDetail).
+		PATCH("/api/v1/k8s/pv/patch", Pv.PatchPv).
 		DELETE("/api/v1/k8s/pv/del", Pv.DeletePv).
 		/* PVC相关路由 */
 		GET("/api/v1/k8s/pvcs", Pvc.GetPvcs).
 		GET("/api/v1/k8s/pvc/detail", Pvc.GetPvcDetail).
+		PATCH("/api/v1/k8s/pvc/patch", Pvc.PatchPvc).
 		PUT("/api/v1/k8s/pvc/update", Pvc.UpdatePvc).
 		DELETE("/api/v1/k8s/pvc/del", Pvc.DeletePvc).
 		/* node相关路由 */
 		GET("/api/v1/k8s/nodes", Node.GetNodes).
 		GET("/api/v1/k8s/node/detail", Node.GetNodeDetail).
+		PATCH("/api/v1/k8s/node/patch", Node.PatchNode).
 		/* configmap相关路由 */
 		GET("/api/v1/k8s/configmaps", ConfigMap.GetConfigMaps).
 		GET("/api/v1/k8s/configmap/detail", ConfigMap.GetConfigMapDetail).
+		PATCH("/api/v1/k8s/configmap/patch", ConfigMap.PatchConfigMap).
 		PUT("/api/v1/k8s/configmap/update", ConfigMap.UpdateConfigMap).
 		DELETE("/api/v1/k8s/configmap/del", ConfigMap.DeleteConfigMap).
 		/* Secret秘钥相关理由 */
 		GET("/api/v1/k8s/secrets", Secret.GetSecrets).
 		GET("/api/v1/k8s/secret/detail", Secret.GetSecretDetail).
+		PATCH("/api/v1/k8s/secret/patch", Secret.PatchSecret).
 		DELETE("/api/v1/k8s/secret/del", Secret.DeleteSecret).
-		PUT("/api/v1/k8s/secret/update", Secret.UpdateSecret)
+		PUT("/api/v1/k8s/secret/update", Secret.UpdateSecret).
+		/* 通用资源相关路由，通过group、version、resource参数指定资源类型 */
+		GET("/api/v1/k8s/apiresources", Resource.GetApiResources).
+		GET("/api/v1/k8s/resources", Resource.GetResources).
+		GET("/api/v1/k8s/resource/detail", Resource.GetResourceDetail).
+		POST("/api/v1/k8s/resource/create", Resource.CreateResource).
+		PUT("/api/v1/k8s/resource/update", Resource.UpdateResource).
+		PATCH("/api/v1/k8s/resource/patch", Resource.PatchResource).
+		DELETE("/api/v1/k8s/resource/del", Resource.DeleteResource).
+		/* CRD相关路由 */
+		GET("/api/v1/k8s/crds", Crd.GetCrds).
+		GET("/api/v1/k8s/crd/detail", Crd.GetCrdDetail).
+		GET("/api/v1/k8s/crd/instances", Crd.GetCrdInstances).
+		GET("/api/v1/k8s/crd/instance/yaml", Crd.GetCrdInstanceYaml).
+		PUT("/api/v1/k8s/crd/instance/update", Crd.UpdateCrdInstance).
+		DELETE("/api/v1/k8s/crd/instance/del", Crd.DeleteCrdInstance)
 }
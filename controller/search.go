@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+	"strings"
+)
+
+var Search search
+
+type search struct{}
+
+// Search 按名称、标签、注解全局搜索资源，结果按资源类型分组
+func (s *search) Search(context *gin.Context) {
+	params := new(struct {
+		Keyword   string `form:"keyword"`
+		Namespace string `form:"namespace"`
+		// Kinds 需要搜索的资源类型，逗号分隔，如pods,services，为空时搜索所有类型
+		Kinds string `form:"kinds"`
+		Limit int    `form:"limit"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败，错误信息, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	var kinds []string
+	if params.Kinds != "" {
+		kinds = strings.Split(params.Kinds, ",")
+	}
+	data, err := service.Search.Search(params.Keyword, params.Namespace, kinds, params.Limit)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "搜索成功!",
+		"data":    data,
+	})
+}
@@ -19,6 +19,7 @@ func (s *secret) GetSecrets(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind参数失败,错误信息," + err.Error())
@@ -28,7 +29,7 @@ func (s *secret) GetSecrets(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.Secret.GetSecrets(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.Secret.GetSecrets(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -47,6 +48,7 @@ func (s *secret) GetSecretDetail(context *gin.Context) {
 	params := new(struct {
 		SecretName string `form:"secretName"`
 		Namespace  string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error(errors.New("获取secret " + params.SecretName + "详细失败,错误信息, " + err.Error()))
@@ -64,9 +66,17 @@ func (s *secret) GetSecretDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取Secret " + params.SecretName + "成功!",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -116,6 +126,13 @@ func (s *secret) UpdateSecret(context *gin.Context) {
 	}
 	err := service.Secret.UpdateSecret(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -127,3 +144,42 @@ func (s *secret) UpdateSecret(context *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// PatchSecret 对Secret打补丁，只需提交要修改的字段
+func (s *secret) PatchSecret(context *gin.Context) {
+	params := new(struct {
+		SecretName string `json:"secretName"`
+		Namespace  string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Secret.PatchSecret(params.SecretName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Secret成功!",
+		"data":    data,
+	})
+}
@@ -18,6 +18,7 @@ func (s *servicev1) GetServices(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败,错误信息," + err.Error())
@@ -27,7 +28,7 @@ func (s *servicev1) GetServices(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.Servicev1.GetServices(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.Servicev1.GetServices(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -46,6 +47,7 @@ func (s *servicev1) GetServiceDetail(context *gin.Context) {
 	params := new(struct {
 		ServiceName string `form:"service_name"`
 		Namespace   string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind请求参数失败,错误信息, " + err.Error())
@@ -63,9 +65,17 @@ func (s *servicev1) GetServiceDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取Service详情成功!",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -133,6 +143,13 @@ func (s *servicev1) UpdateService(context *gin.Context) {
 	}
 	err := service.Servicev1.UpdateService(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -144,3 +161,42 @@ func (s *servicev1) UpdateService(context *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// PatchService 对Service打补丁，只需提交要修改的字段
+func (s *servicev1) PatchService(context *gin.Context) {
+	params := new(struct {
+		ServiceName string `json:"serviceName"`
+		Namespace   string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Servicev1.PatchService(params.ServiceName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改Service成功!",
+		"data":    data,
+	})
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"NativeSphere/service"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/wonderivan/logger"
 	"net/http"
+	"strconv"
 )
 
 var StatefulSet statefulSet
@@ -18,6 +20,7 @@ func (s *statefulSet) GetStatefulSets(context *gin.Context) {
 		Namespace  string `form:"namespace"`
 		Page       int    `form:"page"`
 		Limit      int    `form:"limit"`
+		service.SelectParams
 	})
 	if err := context.Bind(params); err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
@@ -26,7 +29,7 @@ func (s *statefulSet) GetStatefulSets(context *gin.Context) {
 		})
 		return
 	}
-	data, err := service.StatefulSet.GetStatefulSets(params.FilterName, params.Namespace, params.Limit, params.Page)
+	data, err := service.StatefulSet.GetStatefulSets(params.FilterName, params.Namespace, params.Limit, params.Page, &params.SelectParams)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
@@ -45,6 +48,7 @@ func (s *statefulSet) GetStatefulSetDetail(context *gin.Context) {
 	params := new(struct {
 		StatefulSetName string `form:"statefulset_name"`
 		Namespace       string `form:"namespace"`
+		service.DetailFormat
 	})
 	if err := context.Bind(params); err != nil {
 		logger.Error("Bind参数失败，错误信息" + err.Error())
@@ -62,10 +66,18 @@ func (s *statefulSet) GetStatefulSetDetail(context *gin.Context) {
 		})
 		return
 	}
+	content, err := service.FormatDetail(data, &params.DetailFormat)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
 
 	context.JSON(http.StatusOK, gin.H{
 		"message": "获取StatefulSet详情成功",
-		"data":    data,
+		"data":    content,
 	})
 }
 
@@ -99,6 +111,97 @@ func (s *statefulSet) DeleteStatefulSet(ctx *gin.Context) {
 	})
 }
 
+// CreateStatefulSet 创建statefulSet
+func (s *statefulSet) CreateStatefulSet(context *gin.Context) {
+	var (
+		statefulSetCreate = new(service.StatefulSetCreate)
+		err               error
+	)
+	if err = context.ShouldBindJSON(statefulSetCreate); err != nil {
+		logger.Error("Bind请求参数失败，" + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err = service.StatefulSet.CreateStatefulSet(statefulSetCreate); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "创建statefulSet" + statefulSetCreate.Name + "成功",
+		"data":    nil,
+	})
+}
+
+// ScaleStatefulSet 设置statefulSet副本数
+func (s *statefulSet) ScaleStatefulSet(context *gin.Context) {
+	params := new(struct {
+		StatefulSetName string `json:"statefulset_name"`
+		Namespace       string `json:"namespace"`
+		ScaleNum        int    `json:"scale_num"`
+	})
+	//PUT请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.StatefulSet.ScaleStatefulSet(params.StatefulSetName, params.Namespace, params.ScaleNum)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	response := gin.H{
+		"message": "设置statefulSet" + params.StatefulSetName + "副本数至" + strconv.Itoa(params.ScaleNum) + "成功",
+		"data":    fmt.Sprintf("最新副本数：%d\n", data),
+	}
+	// statefulSet由hpa管理时，手动设置的副本数会被覆盖，返回提示
+	if warning := service.HPA.ScaleWarning("StatefulSet", params.StatefulSetName, params.Namespace, data); warning != "" {
+		response["warning"] = warning
+	}
+	context.JSON(http.StatusOK, response)
+}
+
+// RestartStatefulSet 重启StatefulSet
+func (s *statefulSet) RestartStatefulSet(context *gin.Context) {
+	params := new(struct {
+		StatefulSetName string `json:"statefulset_name"`
+		Namespace       string `json:"namespace"`
+	})
+	//PUT请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	err := service.StatefulSet.RestartStatefulSet(params.StatefulSetName, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "重启StatefulSet成功",
+		"data":    nil,
+	})
+}
+
 // UpdateStatefulSet 更新statefulSet
 func (s *statefulSet) UpdateStatefulSet(context *gin.Context) {
 	params := new(struct {
@@ -117,6 +220,13 @@ func (s *statefulSet) UpdateStatefulSet(context *gin.Context) {
 
 	err := service.StatefulSet.UpdateStatefulSet(params.Namespace, params.Content)
 	if err != nil {
+		if conflict, ok := service.AsConflict(err); ok {
+			context.JSON(http.StatusConflict, gin.H{
+				"message": err.Error(),
+				"data":    conflict,
+			})
+			return
+		}
 		context.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"data":    nil,
@@ -128,3 +238,42 @@ func (s *statefulSet) UpdateStatefulSet(context *gin.Context) {
 		"data":    nil,
 	})
 }
+
+// PatchStatefulSet 对StatefulSet打补丁，只需提交要修改的字段
+func (s *statefulSet) PatchStatefulSet(context *gin.Context) {
+	params := new(struct {
+		StatefulSetName string `json:"statefulset_name"`
+		Namespace       string `json:"namespace"`
+		// PatchType 补丁类型，可选值为json、merge、strategic，默认为merge
+		PatchType string `json:"patch_type"`
+		Content   string `json:"content"`
+	})
+	// PATCH请求，绑定参数方法为ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.CheckPatch(params.PatchType, params.Content); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.StatefulSet.PatchStatefulSet(params.StatefulSetName, params.Namespace, params.PatchType, params.Content)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "修改StatefulSet成功!",
+		"data":    data,
+	})
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"NativeSphere/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Terminal terminal
+
+type terminal struct{}
+
+// GetSessions 获取活跃的终端会话列表，非管理员只返回自己的会话
+func (t *terminal) GetSessions(context *gin.Context) {
+	params := new(struct {
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data := service.Terminal.ListSessions(params.Namespace, currentUser(context))
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取终端会话列表成功",
+		"data":    data,
+	})
+}
+
+// KillSession 强制关闭终端会话，非管理员只能关闭自己的会话
+func (t *terminal) KillSession(context *gin.Context) {
+	params := new(struct {
+		SessionId string `json:"session_id"`
+	})
+	// DELETE请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.Terminal.KillSession(params.SessionId, currentUser(context)); err != nil {
+		if service.IsAccessDenied(err) {
+			context.JSON(http.StatusForbidden, gin.H{
+				"message": err.Error(),
+				"data":    nil,
+			})
+			return
+		}
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "关闭终端会话" + params.SessionId + "成功",
+		"data":    nil,
+	})
+}
+
+// ShareSession 开启终端会话的只读共享，只有会话所有者可以操作
+func (t *terminal) ShareSession(context *gin.Context) {
+	params := new(struct {
+		SessionId string `json:"session_id"`
+	})
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Terminal.Share(params.SessionId, currentUser(context))
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "共享终端会话" + params.SessionId + "成功",
+		"data":    data,
+	})
+}
+
+// GetViewers 获取终端会话的观察者列表
+func (t *terminal) GetViewers(context *gin.Context) {
+	params := new(struct {
+		SessionId string `form:"session_id"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Terminal.ListViewers(params.SessionId, currentUser(context))
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取终端会话观察者列表成功",
+		"data":    data,
+	})
+}
+
+// RevokeViewer 移除终端会话的观察者，ban为true时禁止该用户再次加入
+func (t *terminal) RevokeViewer(context *gin.Context) {
+	params := new(struct {
+		SessionId string `json:"session_id"`
+		ViewerId  string `json:"viewer_id"`
+		Ban       bool   `json:"ban"`
+	})
+	// DELETE请求，绑定参数方法改为ctx.ShouldBindJSON
+	if err := context.ShouldBindJSON(params); err != nil {
+		logger.Error("Bind请求参数失败, " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	if err := service.Terminal.RevokeViewer(params.SessionId, params.ViewerId, currentUser(context), params.Ban); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "移除观察者" + params.ViewerId + "成功",
+		"data":    nil,
+	})
+}
+
+// currentUser 从jwt中间件解析的claims中获取当前用户名
+func currentUser(context *gin.Context) string {
+	if claims, ok := context.Get("claims"); ok {
+		if customClaims, ok := claims.(*utils.CustomClaims); ok {
+			return customClaims.Username
+		}
+	}
+	return "anonymous"
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"NativeSphere/service"
+	"github.com/gin-gonic/gin"
+	"github.com/wonderivan/logger"
+	"net/http"
+)
+
+var Tree tree
+
+type tree struct{}
+
+// GetWorkloadTree 获取工作负载的owner关系树，包括子资源、owner链以及关联的Service和Ingress
+func (t *tree) GetWorkloadTree(context *gin.Context) {
+	params := new(struct {
+		Kind      string `form:"kind"`
+		Name      string `form:"name"`
+		Namespace string `form:"namespace"`
+	})
+	if err := context.Bind(params); err != nil {
+		logger.Error("Bind请求参数失败,错误信息 " + err.Error())
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	data, err := service.Tree.GetWorkloadTree(params.Kind, params.Name, params.Namespace)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message": "获取" + params.Kind + " " + params.Name + "的owner关系树成功",
+		"data":    data,
+	})
+}
@@ -48,9 +48,13 @@ func main() {
 	controller.Router.InitApiRouter(router)
 	// 打印彩色终端
 	utils.PrintColor()
+	// 启动终端会话超时检查
+	go service.Terminal.RunSessionReaper()
 	// 启动websocket服务(因websocket是异步函数,需在gin启动之前启动)
 	go func() {
 		http.HandleFunc("/ws", service.Terminal.WsHandler)
+		http.HandleFunc("/ws/portforward", service.PortForward.WsHandler)
+		http.HandleFunc("/ws/watch", service.Watch.WsHandler)
 		http.ListenAndServe(config.WebSocketListenAddr, nil)
 	}()
 	// gin程序启动
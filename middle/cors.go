@@ -15,7 +15,7 @@ func Cores() gin.HandlerFunc {
 		context.Header("Content-Type", "application/json")
 		context.Header("Access-Control-Allow-Origin", "*")
 		context.Header("Access-Control-Max-Age", "86400")
-		context.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
+		context.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE, UPDATE")
 		context.Header("Access-Control-Allow-Headers", "X-Token, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Max")
 		context.Header("Access-Control-Allow-Credentials", "false")
 
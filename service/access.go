@@ -0,0 +1,88 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"NativeSphere/utils"
+	"context"
+	"errors"
+	"github.com/wonderivan/logger"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+)
+
+// 用于websocket等不经过gin中间件的接口做身份识别和RBAC权限校验
+
+// getRequestUser 从请求中解析jwt token并返回用户名
+// websocket握手时浏览器无法自定义header，所以同时支持从token参数中获取
+func getRequestUser(r *http.Request) (username string, err error) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return "", errors.New("请求未携带token,无权限访问")
+	}
+	claims, err := utils.JWTToken.ParseToken(token)
+	if err != nil {
+		return "", err
+	}
+	return claims.Username, nil
+}
+
+// AccessDeniedError RBAC校验未通过时返回的错误，调用方据此返回403，与校验请求本身失败区分
+type AccessDeniedError struct {
+	message string
+}
+
+func (e *AccessDeniedError) Error() string {
+	return e.message
+}
+
+// IsAccessDenied 判断错误是否为RBAC校验未通过
+func IsAccessDenied(err error) bool {
+	var denied *AccessDeniedError
+	return errors.As(err, &denied)
+}
+
+// accessReviewUser 将平台用户映射为k8s用户
+func accessReviewUser(username string) string {
+	if user, ok := config.AccessReviewUsers[username]; ok {
+		return user
+	}
+	return config.AccessReviewUserPrefix + username
+}
+
+// checkAccess 使用SubjectAccessReview校验用户是否拥有对应资源的操作权限，平台用户按配置映射为k8s用户和组
+// 例如port-forward需要校验 verb=create, resource=pods, subresource=portforward，core组的资源group为空
+// 权限不足时返回AccessDeniedError
+func checkAccess(username, namespace, verb, group, resource, subresource, name string) (err error) {
+	if !config.AccessReviewEnabled {
+		return nil
+	}
+	user := accessReviewUser(username)
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user,
+			Groups: config.AccessReviewGroups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       group,
+				Resource:    resource,
+				Subresource: subresource,
+				Name:        name,
+			},
+		},
+	}
+	result, err := K8s.ClientSet.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("校验用户 " + username + "权限失败,错误信息 " + err.Error()))
+		return errors.New("校验用户 " + username + "权限失败,错误信息 " + err.Error())
+	}
+	if !result.Status.Allowed {
+		logger.Error(errors.New("用户 " + username + "(" + user + ")无权限" + verb + " " + resource + "/" + subresource + "," + result.Status.Reason))
+		return &AccessDeniedError{message: "用户 " + username + "(" + user + ")无权限" + verb + " " + resource + "/" + subresource + "," + result.Status.Reason}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	"io"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"strings"
+)
+
+// 多文档apply，接收包含多种资源的yaml或json流，使用server-side apply逐个应用，
+// 单个对象失败不影响其余对象，返回每个对象的结果
+
+// Apply 定义Apply全局变量
+var Apply apply
+
+// 定义apply结构体
+type apply struct{}
+
+// dryRunAll dryRun参数唯一支持的取值，与kubectl --dry-run=server一致
+const dryRunAll = "All"
+
+// ApplyResult 定义单个对象的apply结果，Action为created、configured或unchanged
+type ApplyResult struct {
+	Index      int    `json:"index"`
+	ApiVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Action     string `json:"action"`
+	Error      string `json:"error"`
+}
+
+// ApplyResp 定义apply的返回内容
+type ApplyResp struct {
+	DryRun    bool           `json:"dryRun"`
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Results   []*ApplyResult `json:"results"`
+}
+
+// Apply 使用server-side apply应用content中的所有对象
+// content为多文档yaml(以---分隔)或json，kind为List的对象会展开其items
+// namespace为对象未指定namespace时使用的默认值，dryRun为All时只做服务端校验不持久化，force为true时强制接管字段冲突
+func (a *apply) Apply(namespace, content, dryRun string, force bool) (applyResp *ApplyResp, err error) {
+	if dryRun != "" && dryRun != dryRunAll {
+		logger.Error(errors.New("不支持的dryRun参数 " + dryRun + ",可选值为All"))
+		return nil, errors.New("不支持的dryRun参数 " + dryRun + ",可选值为All")
+	}
+	objects, err := a.decode(content)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	if len(objects) == 0 {
+		logger.Error(errors.New("apply内容中没有资源对象"))
+		return nil, errors.New("apply内容中没有资源对象")
+	}
+
+	applyResp = &ApplyResp{DryRun: dryRun == dryRunAll, Total: len(objects)}
+	for i, obj := range objects {
+		result := &ApplyResult{
+			Index:      i,
+			ApiVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+			Namespace:  obj.GetNamespace(),
+		}
+		if err := a.applyObject(obj, namespace, dryRun, force, result); err != nil {
+			result.Error = err.Error()
+			applyResp.Failed++
+		} else {
+			applyResp.Succeeded++
+		}
+		applyResp.Results = append(applyResp.Results, result)
+	}
+	return applyResp, nil
+}
+
+// decode 解析yaml或json流中的所有对象，跳过空文档
+func (a *apply) decode(content string) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(content), 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		object := map[string]interface{}{}
+		if err := decoder.Decode(&object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("解析第%d个文档失败,错误信息 %s", len(objects)+1, err.Error())
+		}
+		if len(object) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: object}
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, errors.New("解析List失败,错误信息 " + err.Error())
+			}
+		} else {
+			objects = append(objects, obj)
+		}
+		if len(objects) > config.ApplyMaxDocuments {
+			return nil, fmt.Errorf("单次apply的对象数量不能超过%d", config.ApplyMaxDocuments)
+		}
+	}
+	return objects, nil
+}
+
+// applyObject 应用单个对象，并根据apply前后的resourceVersion判断执行的动作
+func (a *apply) applyObject(obj *unstructured.Unstructured, namespace, dryRun string, force bool, result *ApplyResult) error {
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return errors.New("对象缺少apiVersion或kind")
+	}
+	if obj.GetName() == "" {
+		return errors.New("对象缺少metadata.name，apply不支持generateName")
+	}
+	gvk := obj.GroupVersionKind()
+	client, _, err := objectClient(obj, namespace)
+	if err != nil {
+		return err
+	}
+	result.Namespace = obj.GetNamespace()
+
+	// 获取当前对象用于判断created、configured或unchanged
+	liveVersion := ""
+	live, err := client.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.New("获取" + gvk.Kind + " " + obj.GetName() + "失败,错误信息 " + err.Error())
+	}
+	if err == nil {
+		liveVersion = live.GetResourceVersion()
+	}
+
+	// apply请求中不能包含managedFields，从导出的yaml中apply时需要去掉
+	obj.SetManagedFields(nil)
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return errors.New("序列化对象失败,错误信息 " + err.Error())
+	}
+	options := metav1.PatchOptions{FieldManager: config.ApplyFieldManager, Force: &force}
+	if dryRun != "" {
+		options.DryRun = []string{dryRun}
+	}
+	applied, err := client.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, options)
+	if err != nil {
+		logger.Error(errors.New("apply " + gvk.Kind + " " + obj.GetName() + "失败,错误信息 " + err.Error()))
+		return errors.New("apply " + gvk.Kind + " " + obj.GetName() + "失败,错误信息 " + err.Error())
+	}
+	switch {
+	case liveVersion == "":
+		result.Action = "created"
+	case dryRun == "" && applied.GetResourceVersion() == liveVersion:
+		result.Action = "unchanged"
+	default:
+		result.Action = "configured"
+	}
+	return nil
+}
+
+// objectClient 根据对象的apiVersion和kind获取对应的dynamic client
+// 集群级别的资源去掉namespace，namespace级别的资源未指定namespace时使用namespace参数，参数也为空时使用default
+func objectClient(obj *unstructured.Unstructured, namespace string) (client dynamic.ResourceInterface, gvr schema.GroupVersionResource, err error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := restMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, gvr, errors.New("资源类型 " + gvk.String() + "不存在,错误信息 " + err.Error())
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		obj.SetNamespace("")
+		return K8s.DynamicClient.Resource(mapping.Resource), mapping.Resource, nil
+	}
+	if obj.GetNamespace() == "" {
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		obj.SetNamespace(namespace)
+	}
+	return K8s.DynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()), mapping.Resource, nil
+}
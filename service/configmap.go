@@ -18,12 +18,19 @@ type configMap struct{}
 type ConfigMapsResp struct {
 	Items []corev1.ConfigMap `json:"items"`
 	Total int                `json:"total"`
+	ListMeta
 }
 
 // GetConfigMaps 获取configmap列表、支持过滤、排序、分页
-func (c *configMap) GetConfigMaps(filterName, namespace string, limit, page int) (configMapsResp *ConfigMapsResp, err error) {
+func (c *configMap) GetConfigMaps(filterName, namespace string, limit, page int, selectParams *SelectParams) (configMapsResp *ConfigMapsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取configmapList类型的configMap
-	configMapList, err := K8s.ClientSet.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{})
+	configMapList, err := K8s.ClientSet.CoreV1().ConfigMaps(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取namespace " + namespace + "下configMap " + filterName + "失败,错误信息 " + err.Error()))
 		return nil, errors.New("获取namespace " + namespace + "下configMap " + filterName + "失败,错误信息 " + err.Error())
@@ -31,25 +38,19 @@ func (c *configMap) GetConfigMaps(filterName, namespace string, limit, page int)
 	// 将configMapList中的configMap列表(items),放进dataselector对象中,进行排序
 	selectableData := &dataSelector{
 		GenericDataList: c.toCells(configMapList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{
-				Name: filterName,
-			},
-			Paginate: &PaginateQuery{
-				limit, page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(configMapList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的configmap列表转为v1.configmap列表
 	configMaps := c.fromCells(data.GenericDataList)
 
 	return &ConfigMapsResp{
-		Items: configMaps,
-		Total: total,
+		Items:    configMaps,
+		Total:    total,
+		ListMeta: newListMeta(configMapList.ListMeta),
 	}, nil
 }
 
@@ -83,12 +84,30 @@ func (c *configMap) UpdateConfigMap(namespace, content string) (err error) {
 	}
 	_, err = K8s.ClientSet.CoreV1().ConfigMaps(namespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "ConfigMap", corev1.SchemeGroupVersion.WithResource("configmaps"), namespace, configMap); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新ConfigMap失败,错误信息 " + err.Error()))
 		return errors.New("更新ConfigMap失败,错误信息 " + err.Error())
 	}
 	return nil
 }
 
+// PatchConfigMap 对ConfigMap打补丁，patchType支持json、merge、strategic，默认为merge
+func (c *configMap) PatchConfigMap(configMapName, namespace, patchType, content string) (configMap *corev1.ConfigMap, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	configMap, err = K8s.ClientSet.CoreV1().ConfigMaps(namespace).Patch(context.TODO(), configMapName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改ConfigMap " + configMapName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改ConfigMap " + configMapName + "失败,错误信息 " + err.Error())
+	}
+	return configMap, nil
+}
+
 //
 func (c *configMap) toCells(std []corev1.ConfigMap) []DataCell {
 	cells := make([]DataCell, len(std))
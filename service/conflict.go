@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// 更新冲突，更新时提交的resourceVersion与资源当前的resourceVersion不一致时apiserver返回409，
+// 转换为ConflictError并带上资源当前的resourceVersion，调用方可以据此刷新后重新提交
+
+// ConflictError 定义更新冲突的错误
+type ConflictError struct {
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	SubmittedVersion string `json:"submittedVersion"`
+	CurrentVersion   string `json:"currentVersion"`
+}
+
+// Error 实现error接口
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s已被修改,提交的resourceVersion为%s,当前resourceVersion为%s,请获取最新内容后重试",
+		e.Kind, e.Name, e.SubmittedVersion, e.CurrentVersion)
+}
+
+// AsConflict 判断错误是否为更新冲突
+func AsConflict(err error) (*ConflictError, bool) {
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		return conflict, true
+	}
+	return nil, false
+}
+
+// conflictError 将apiserver返回的冲突错误转换为ConflictError，不是冲突错误时返回nil
+// 通过metadata client获取资源当前的resourceVersion，获取失败时CurrentVersion为空
+func conflictError(err error, kind string, gvr schema.GroupVersionResource, namespace string, objectMeta metav1.Object) *ConflictError {
+	if !apierrors.IsConflict(err) {
+		return nil
+	}
+	conflict := &ConflictError{
+		Kind:             kind,
+		Name:             objectMeta.GetName(),
+		Namespace:        namespace,
+		SubmittedVersion: objectMeta.GetResourceVersion(),
+	}
+	current, getErr := K8s.MetadataClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), conflict.Name, metav1.GetOptions{})
+	if getErr != nil {
+		logger.Error(errors.New("获取" + kind + " " + conflict.Name + "当前版本失败,错误信息 " + getErr.Error()))
+	} else {
+		conflict.CurrentVersion = current.GetResourceVersion()
+	}
+	logger.Error(conflict)
+	return conflict
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"github.com/wonderivan/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// deployment、statefulSet、daemonSet以及job创建表单共用的容器组装方法
+
+// newContainer 根据表单参数组装容器，HealthCheck为true时设置http探针和资源配额
+func newContainer(name, image string, containerPort int32, cpu, memory string, healthCheck bool, healthPath string) (corev1.Container, error) {
+	container := corev1.Container{
+		Name:  name,
+		Image: image,
+	}
+	if containerPort > 0 {
+		container.Ports = []corev1.ContainerPort{{
+			Name:          "http",
+			Protocol:      corev1.ProtocolTCP,
+			ContainerPort: containerPort,
+		}}
+	}
+	if !healthCheck {
+		return container, nil
+	}
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: healthPath,
+				Port: intstr.FromInt(int(containerPort)),
+			},
+		},
+		InitialDelaySeconds: 15,
+		TimeoutSeconds:      5,
+		PeriodSeconds:       5,
+	}
+	container.ReadinessProbe = probe
+	container.LivenessProbe = probe.DeepCopy()
+	resources, err := parseResources(cpu, memory)
+	if err != nil {
+		return container, err
+	}
+	container.Resources.Limits = resources
+	container.Resources.Requests = resources.DeepCopy()
+	return container, nil
+}
+
+// parseResources 解析cpu和memory配额，为空的配额不设置
+func parseResources(cpu, memory string) (corev1.ResourceList, error) {
+	resources := corev1.ResourceList{}
+	for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			logger.Error(errors.New(string(name) + "配额 " + value + "格式错误,错误信息 " + err.Error()))
+			return nil, errors.New(string(name) + "配额 " + value + "格式错误,错误信息 " + err.Error())
+		}
+		resources[name] = quantity
+	}
+	return resources, nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/wonderivan/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+	"strings"
+	"time"
+)
+
+// CRD浏览，通过dynamic client读取apiextensions.k8s.io/v1的CRD定义，
+// 实例的增删改查复用通用资源接口，列表按CRD的additionalPrinterColumns渲染额外的列
+
+// Crd 定义Crd全局变量
+var Crd crd
+
+// 定义crd结构体
+type crd struct{}
+
+// crdResource CRD本身的GVR
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// customResourceDefinition 定义CRD中用到的字段，避免引入apiextensions依赖
+type customResourceDefinition struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		Group string `json:"group"`
+		Names struct {
+			Plural     string   `json:"plural"`
+			Singular   string   `json:"singular"`
+			Kind       string   `json:"kind"`
+			ShortNames []string `json:"shortNames"`
+		} `json:"names"`
+		Scope    string       `json:"scope"`
+		Versions []CrdVersion `json:"versions"`
+	} `json:"spec"`
+}
+
+// CrdInfo 定义CRD的展示信息
+type CrdInfo struct {
+	Name              string       `json:"name"`
+	Group             string       `json:"group"`
+	Kind              string       `json:"kind"`
+	Plural            string       `json:"plural"`
+	Singular          string       `json:"singular"`
+	ShortNames        []string     `json:"shortNames"`
+	Scope             string       `json:"scope"`
+	Versions          []CrdVersion `json:"versions"`
+	CreationTimestamp time.Time    `json:"creationTimestamp"`
+}
+
+// CrdVersion 定义CRD的版本信息
+type CrdVersion struct {
+	Name           string             `json:"name"`
+	Served         bool               `json:"served"`
+	Storage        bool               `json:"storage"`
+	PrinterColumns []CrdPrinterColumn `json:"additionalPrinterColumns"`
+}
+
+// CrdPrinterColumn 定义CRD实例列表中额外展示的列，jsonPath相对于实例对象，type为date的列以age返回
+type CrdPrinterColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format"`
+	Description string `json:"description"`
+	Priority    int32  `json:"priority"`
+	JSONPath    string `json:"jsonPath"`
+}
+
+// CrdsResp 定义CRD列表的返回内容
+type CrdsResp struct {
+	Items []*CrdInfo `json:"items"`
+	Total int        `json:"total"`
+	ListMeta
+}
+
+// CrdInstance 定义CRD实例的展示信息，Cells与Columns一一对应
+type CrdInstance struct {
+	Name              string                     `json:"name"`
+	Namespace         string                     `json:"namespace"`
+	CreationTimestamp time.Time                  `json:"creationTimestamp"`
+	Cells             []string                   `json:"cells"`
+	Object            *unstructured.Unstructured `json:"object"`
+}
+
+// CrdInstancesResp 定义CRD实例列表的返回内容
+type CrdInstancesResp struct {
+	Version string             `json:"version"`
+	Columns []CrdPrinterColumn `json:"columns"`
+	Items   []*CrdInstance     `json:"items"`
+	Total   int                `json:"total"`
+	ListMeta
+}
+
+// GetCrds 获取集群中安装的CRD列表，支持过滤、排序、分页
+func (c *crd) GetCrds(filterName string, limit, page int, selectParams *SelectParams) (crdsResp *CrdsResp, err error) {
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	crdList, err := K8s.DynamicClient.Resource(crdResource).List(context.TODO(), dataSelectQuery.ListOptions())
+	if err != nil {
+		logger.Error(errors.New("获取CRD列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取CRD列表失败,错误信息 " + err.Error())
+	}
+	selectableData := &dataSelector{
+		GenericDataList: Resource.toCells(crdList.Items),
+		DataSelectQuery: dataSelectQuery,
+	}
+	filtered := selectableData.Filter()
+	total := filtered.Total(crdList.GetRemainingItemCount())
+	data := filtered.Sort().Paginate()
+
+	items := make([]*CrdInfo, 0, len(data.GenericDataList))
+	for _, obj := range Resource.fromCells(data.GenericDataList) {
+		info, err := c.toCrdInfo(&obj)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, info)
+	}
+	return &CrdsResp{
+		Items: items,
+		Total: total,
+		ListMeta: ListMeta{
+			Continue:           crdList.GetContinue(),
+			RemainingItemCount: crdList.GetRemainingItemCount(),
+		},
+	}, nil
+}
+
+// GetCrdDetail 获取CRD详情
+func (c *crd) GetCrdDetail(crdName string) (info *CrdInfo, err error) {
+	obj, err := K8s.DynamicClient.Resource(crdResource).Get(context.TODO(), crdName, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取CRD " + crdName + "详情失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取CRD " + crdName + "详情失败,错误信息 " + err.Error())
+	}
+	return c.toCrdInfo(obj)
+}
+
+// GetCrdInstances 获取CRD实例列表，并按版本的printer columns渲染额外的列
+// version为空时使用storage版本
+func (c *crd) GetCrdInstances(crdName, version, filterName, namespace string, limit, page int, selectParams *SelectParams) (instancesResp *CrdInstancesResp, err error) {
+	info, err := c.GetCrdDetail(crdName)
+	if err != nil {
+		return nil, err
+	}
+	crdVersion, err := info.version(version)
+	if err != nil {
+		return nil, err
+	}
+	resourcesResp, err := Resource.GetResources(info.resourceType(crdVersion.Name), filterName, namespace, limit, page, selectParams)
+	if err != nil {
+		return nil, err
+	}
+	parsers, err := c.columnParsers(crdVersion.PrinterColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*CrdInstance, 0, len(resourcesResp.Items))
+	for i := range resourcesResp.Items {
+		obj := &resourcesResp.Items[i]
+		cells := make([]string, len(parsers))
+		for j, parser := range parsers {
+			cells[j] = c.renderColumn(parser, obj)
+			if crdVersion.PrinterColumns[j].Type == "date" && cells[j] != "" {
+				cells[j] = dateToAge(cells[j])
+			}
+		}
+		items = append(items, &CrdInstance{
+			Name:              obj.GetName(),
+			Namespace:         obj.GetNamespace(),
+			CreationTimestamp: obj.GetCreationTimestamp().Time,
+			Cells:             cells,
+			Object:            obj,
+		})
+	}
+	return &CrdInstancesResp{
+		Version:  crdVersion.Name,
+		Columns:  crdVersion.PrinterColumns,
+		Items:    items,
+		Total:    resourcesResp.Total,
+		ListMeta: resourcesResp.ListMeta,
+	}, nil
+}
+
+// GetCrdInstanceYaml 获取CRD实例的yaml内容
+func (c *crd) GetCrdInstanceYaml(crdName, version, name, namespace string) (content string, err error) {
+	info, err := c.GetCrdDetail(crdName)
+	if err != nil {
+		return "", err
+	}
+	crdVersion, err := info.version(version)
+	if err != nil {
+		return "", err
+	}
+	obj, err := Resource.GetResourceDetail(info.resourceType(crdVersion.Name), name, namespace)
+	if err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		logger.Error(errors.New("序列化yaml失败,错误信息 " + err.Error()))
+		return "", errors.New("序列化yaml失败,错误信息 " + err.Error())
+	}
+	return string(data), nil
+}
+
+// UpdateCrdInstanceYaml 使用yaml内容更新CRD实例，版本以yaml中的apiVersion为准
+func (c *crd) UpdateCrdInstanceYaml(crdName, namespace, content string) (obj *unstructured.Unstructured, err error) {
+	info, err := c.GetCrdDetail(crdName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.YAMLToJSON([]byte(content))
+	if err != nil {
+		logger.Error(errors.New("解析yaml失败,错误信息 " + err.Error()))
+		return nil, errors.New("解析yaml失败,错误信息 " + err.Error())
+	}
+	obj, err = Resource.decode(string(data))
+	if err != nil {
+		return nil, err
+	}
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil || gv.Group != info.Group || obj.GetKind() != info.Kind {
+		logger.Error(errors.New("yaml内容不是CRD " + crdName + "的实例"))
+		return nil, errors.New("yaml内容不是CRD " + crdName + "的实例")
+	}
+	return Resource.UpdateResource(info.resourceType(gv.Version), namespace, string(data))
+}
+
+// DeleteCrdInstance 删除CRD实例
+func (c *crd) DeleteCrdInstance(crdName, name, namespace string) (err error) {
+	info, err := c.GetCrdDetail(crdName)
+	if err != nil {
+		return err
+	}
+	crdVersion, err := info.version("")
+	if err != nil {
+		return err
+	}
+	return Resource.DeleteResource(info.resourceType(crdVersion.Name), name, namespace)
+}
+
+// toCrdInfo 将unstructured格式的CRD转换为展示信息
+func (c *crd) toCrdInfo(obj *unstructured.Unstructured) (*CrdInfo, error) {
+	definition := &customResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, definition); err != nil {
+		logger.Error(errors.New("解析CRD " + obj.GetName() + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("解析CRD " + obj.GetName() + "失败,错误信息 " + err.Error())
+	}
+	return &CrdInfo{
+		Name:              definition.Name,
+		Group:             definition.Spec.Group,
+		Kind:              definition.Spec.Names.Kind,
+		Plural:            definition.Spec.Names.Plural,
+		Singular:          definition.Spec.Names.Singular,
+		ShortNames:        definition.Spec.Names.ShortNames,
+		Scope:             definition.Spec.Scope,
+		Versions:          definition.Spec.Versions,
+		CreationTimestamp: definition.CreationTimestamp.Time,
+	}, nil
+}
+
+// version 获取CRD的指定版本，name为空时返回storage版本
+func (i *CrdInfo) version(name string) (*CrdVersion, error) {
+	for idx := range i.Versions {
+		version := &i.Versions[idx]
+		if (name == "" && version.Storage) || (name != "" && version.Name == name) {
+			if !version.Served {
+				return nil, errors.New("CRD " + i.Name + "的版本 " + version.Name + "未启用")
+			}
+			return version, nil
+		}
+	}
+	return nil, errors.New("CRD " + i.Name + "不存在版本 " + name)
+}
+
+// resourceType 获取CRD指定版本对应的资源类型
+func (i *CrdInfo) resourceType(version string) *ResourceType {
+	return &ResourceType{
+		Group:    i.Group,
+		Version:  version,
+		Resource: i.Plural,
+	}
+}
+
+// columnParsers 解析printer columns中的jsonPath，格式与kubectl一致，如.spec.replicas
+func (c *crd) columnParsers(columns []CrdPrinterColumn) ([]*jsonpath.JSONPath, error) {
+	parsers := make([]*jsonpath.JSONPath, len(columns))
+	for i, column := range columns {
+		path := column.JSONPath
+		if !strings.HasPrefix(path, "{") {
+			path = "{" + path + "}"
+		}
+		parser := jsonpath.New(column.Name).AllowMissingKeys(true)
+		if err := parser.Parse(path); err != nil {
+			logger.Error(errors.New("解析列 " + column.Name + "的jsonPath失败,错误信息 " + err.Error()))
+			return nil, errors.New("解析列 " + column.Name + "的jsonPath失败,错误信息 " + err.Error())
+		}
+		parsers[i] = parser
+	}
+	return parsers, nil
+}
+
+// renderColumn 渲染实例在某一列的值，取值失败时返回空字符串
+func (c *crd) renderColumn(parser *jsonpath.JSONPath, obj *unstructured.Unstructured) string {
+	buf := &bytes.Buffer{}
+	if err := parser.Execute(buf, obj.Object); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/wonderivan/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+)
+
+// CronJob 实例化cronJob结构体
+var CronJob cronJob
+
+// 定义cronJob结构体
+type cronJob struct{}
+
+// instantiateAnnotation 手动触发的job带有该注解，与kubectl create job --from=cronjob一致
+const instantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+
+// CronJobsResp 定义cronJob列表的返回内容
+type CronJobsResp struct {
+	Items []batchv1.CronJob `json:"items"`
+	Total int               `json:"total"`
+	ListMeta
+}
+
+// CronJobCreate 定义CronJobCreate结构体，用于创建cronJob需要的参数属性的定义
+// ConcurrencyPolicy可选值为Allow、Forbid、Replace，默认为Allow
+// SuccessfulJobsHistoryLimit和FailedJobsHistoryLimit为保留的历史job数量，为空时使用k8s的默认值
+type CronJobCreate struct {
+	Name                       string            `json:"name"`
+	Namespace                  string            `json:"namespace"`
+	Label                      map[string]string `json:"label"`
+	Schedule                   string            `json:"schedule"`
+	TimeZone                   string            `json:"timeZone"`
+	Suspend                    bool              `json:"suspend"`
+	ConcurrencyPolicy          string            `json:"concurrencyPolicy"`
+	StartingDeadlineSeconds    *int64            `json:"startingDeadlineSeconds"`
+	SuccessfulJobsHistoryLimit *int32            `json:"successfulJobsHistoryLimit"`
+	FailedJobsHistoryLimit     *int32            `json:"failedJobsHistoryLimit"`
+	JobTemplate
+}
+
+// CronJobRun 定义cronJob的一次运行记录，Manual表示手动触发
+type CronJobRun struct {
+	Name              string       `json:"name"`
+	Status            string       `json:"status"`
+	Manual            bool         `json:"manual"`
+	Active            int32        `json:"active"`
+	Succeeded         int32        `json:"succeeded"`
+	Failed            int32        `json:"failed"`
+	StartTime         *metav1.Time `json:"startTime"`
+	CompletionTime    *metav1.Time `json:"completionTime"`
+	CreationTimestamp metav1.Time  `json:"creationTimestamp"`
+}
+
+// CronJobHistoryResp 定义cronJob的运行历史，按创建时间从新到旧排序
+type CronJobHistoryResp struct {
+	Items              []*CronJobRun `json:"items"`
+	Total              int           `json:"total"`
+	LastScheduleTime   *metav1.Time  `json:"lastScheduleTime"`
+	LastSuccessfulTime *metav1.Time  `json:"lastSuccessfulTime"`
+}
+
+// GetCronJobs 获取cronJob列表，支持过滤、排序、分页，可以通过suspend字段按是否暂停过滤
+func (c *cronJob) GetCronJobs(filterName, namespace string, limit, page int, selectParams *SelectParams) (cronJobsResp *CronJobsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	cronJobList, err := K8s.ClientSet.BatchV1().CronJobs(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
+	if err != nil {
+		logger.Error(errors.New("获取cronJob列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取cronJob列表失败,错误信息 " + err.Error())
+	}
+	selectableData := &dataSelector{
+		GenericDataList: c.toCells(cronJobList.Items),
+		DataSelectQuery: dataSelectQuery,
+	}
+	filtered := selectableData.Filter()
+	total := filtered.Total(cronJobList.RemainingItemCount)
+	data := filtered.Sort().Paginate()
+
+	return &CronJobsResp{
+		Items:    c.fromCells(data.GenericDataList),
+		Total:    total,
+		ListMeta: newListMeta(cronJobList.ListMeta),
+	}, nil
+}
+
+// GetCronJobDetail 获取cronJob详情
+func (c *cronJob) GetCronJobDetail(cronJobName, namespace string) (cronJob *batchv1.CronJob, err error) {
+	cronJob, err = K8s.ClientSet.BatchV1().CronJobs(namespace).Get(context.TODO(), cronJobName, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取cronJob " + cronJobName + "详情失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取cronJob " + cronJobName + "详情失败,错误信息 " + err.Error())
+	}
+	return cronJob, nil
+}
+
+// CreateCronJob 创建cronJob，接受CronJobCreate对象
+func (c *cronJob) CreateCronJob(data *CronJobCreate) (err error) {
+	policy := batchv1.ConcurrencyPolicy(data.ConcurrencyPolicy)
+	switch policy {
+	case "":
+		policy = batchv1.AllowConcurrent
+	case batchv1.AllowConcurrent, batchv1.ForbidConcurrent, batchv1.ReplaceConcurrent:
+	default:
+		logger.Error(errors.New("不支持的concurrencyPolicy " + data.ConcurrencyPolicy + ",可选值为Allow、Forbid、Replace"))
+		return errors.New("不支持的concurrencyPolicy " + data.ConcurrencyPolicy + ",可选值为Allow、Forbid、Replace")
+	}
+	spec, err := newJobSpec(data.Name, data.Label, &data.JobTemplate)
+	if err != nil {
+		return err
+	}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      data.Name,
+			Namespace: data.Namespace,
+			Labels:    data.Label,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   data.Schedule,
+			Suspend:                    &data.Suspend,
+			ConcurrencyPolicy:          policy,
+			StartingDeadlineSeconds:    data.StartingDeadlineSeconds,
+			SuccessfulJobsHistoryLimit: data.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     data.FailedJobsHistoryLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: data.Label,
+				},
+				Spec: spec,
+			},
+		},
+	}
+	if data.TimeZone != "" {
+		cronJob.Spec.TimeZone = &data.TimeZone
+	}
+	_, err = K8s.ClientSet.BatchV1().CronJobs(data.Namespace).Create(context.TODO(), cronJob, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("创建cronJob " + data.Name + "失败,错误信息 " + err.Error()))
+		return errors.New("创建cronJob " + data.Name + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// DeleteCronJob 删除cronJob，由cronJob创建的job及pod一并删除
+func (c *cronJob) DeleteCronJob(cronJobName, namespace string) (err error) {
+	err = K8s.ClientSet.BatchV1().CronJobs(namespace).Delete(context.TODO(), cronJobName, backgroundDeleteOptions())
+	if err != nil {
+		logger.Error(errors.New("删除cronJob " + cronJobName + "失败,错误信息 " + err.Error()))
+		return errors.New("删除cronJob " + cronJobName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// TriggerCronJob 立即运行一次cronJob，与kubectl create job --from=cronjob一致
+// 使用cronJob的job模板创建job，并设置ownerReference使其出现在运行历史中，返回创建的job名称
+func (c *cronJob) TriggerCronJob(cronJobName, namespace string) (jobName string, err error) {
+	cronJob, err := c.GetCronJobDetail(cronJobName, namespace)
+	if err != nil {
+		return "", err
+	}
+	annotations := map[string]string{instantiateAnnotation: "manual"}
+	for key, value := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[key] = value
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			// 名称由apiserver在前缀后追加随机字符生成，避免多次触发时重名
+			GenerateName: cronJobName + "-manual-",
+			Namespace:    namespace,
+			Labels:       cronJob.Spec.JobTemplate.Labels,
+			Annotations:  annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+	created, err := K8s.ClientSet.BatchV1().Jobs(namespace).Create(context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("触发cronJob " + cronJobName + "失败,错误信息 " + err.Error()))
+		return "", errors.New("触发cronJob " + cronJobName + "失败,错误信息 " + err.Error())
+	}
+	return created.Name, nil
+}
+
+// SuspendCronJob 暂停或恢复cronJob，暂停后不再调度新的job，已运行的job不受影响
+func (c *cronJob) SuspendCronJob(cronJobName, namespace string, suspend bool) (err error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"suspend": suspend},
+	})
+	if err != nil {
+		logger.Error(errors.New("序列化cronJob补丁失败,错误信息 " + err.Error()))
+		return errors.New("序列化cronJob补丁失败,错误信息 " + err.Error())
+	}
+	action := "恢复"
+	if suspend {
+		action = "暂停"
+	}
+	_, err = K8s.ClientSet.BatchV1().CronJobs(namespace).Patch(context.TODO(), cronJobName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New(action + "cronJob " + cronJobName + "失败,错误信息 " + err.Error()))
+		return errors.New(action + "cronJob " + cronJobName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// GetCronJobHistory 获取cronJob的运行历史，包括定时调度和手动触发的job
+func (c *cronJob) GetCronJobHistory(cronJobName, namespace string) (historyResp *CronJobHistoryResp, err error) {
+	cronJob, err := c.GetCronJobDetail(cronJobName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	jobList, err := K8s.ClientSet.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取cronJob " + cronJobName + "的job失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取cronJob " + cronJobName + "的job失败,错误信息 " + err.Error())
+	}
+	var owned []*batchv1.Job
+	for i := range jobList.Items {
+		if controllerRef := metav1.GetControllerOf(&jobList.Items[i]); controllerRef != nil && controllerRef.UID == cronJob.UID {
+			owned = append(owned, &jobList.Items[i])
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		return owned[j].CreationTimestamp.Before(&owned[i].CreationTimestamp)
+	})
+	historyResp = &CronJobHistoryResp{
+		Items:              []*CronJobRun{},
+		LastScheduleTime:   cronJob.Status.LastScheduleTime,
+		LastSuccessfulTime: cronJob.Status.LastSuccessfulTime,
+	}
+	for _, job := range owned {
+		status, _ := jobStatusOf(job)
+		historyResp.Items = append(historyResp.Items, &CronJobRun{
+			Name:              job.Name,
+			Status:            status,
+			Manual:            job.Annotations[instantiateAnnotation] == "manual",
+			Active:            job.Status.Active,
+			Succeeded:         job.Status.Succeeded,
+			Failed:            job.Status.Failed,
+			StartTime:         job.Status.StartTime,
+			CompletionTime:    job.Status.CompletionTime,
+			CreationTimestamp: job.CreationTimestamp,
+		})
+	}
+	historyResp.Total = len(historyResp.Items)
+	return historyResp, nil
+}
+
+func (c *cronJob) toCells(std []batchv1.CronJob) []DataCell {
+	cells := make([]DataCell, len(std))
+	for i := range std {
+		cells[i] = cronJobCell(std[i])
+	}
+	return cells
+}
+
+func (c *cronJob) fromCells(cells []DataCell) []batchv1.CronJob {
+	cronJobs := make([]batchv1.CronJob, len(cells))
+	for i := range cells {
+		cronJobs[i] = batchv1.CronJob(cells[i].(cronJobCell))
+	}
+	return cronJobs
+}
@@ -7,9 +7,8 @@ import (
 	"github.com/wonderivan/logger"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // DaemonSet 初始化daemonSet结构体
@@ -22,6 +21,7 @@ type daemonSet struct{}
 type DaemonSetsResp struct {
 	Items []appsv1.DaemonSet `json:"items"`
 	Total int                `json:"total"`
+	ListMeta
 }
 
 // DaemonSetCreate 定义DaemonSetCreate结构体，用于创建DaemonSet需要的参数属性的定义
@@ -44,9 +44,15 @@ type DaemonSetNp struct {
 }
 
 // GetDaemonSets 获取DaemonSet列表，支持过滤、排序、分页
-func (d *daemonSet) GetDaemonSets(filterName, namespace string, limit, page int) (deploymentsResp *DaemonSetsResp, err error) {
+func (d *daemonSet) GetDaemonSets(filterName, namespace string, limit, page int, selectParams *SelectParams) (deploymentsResp *DaemonSetsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取deploymentList类型的deployment列表
-	daemonSetList, err := K8s.ClientSet.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	daemonSetList, err := K8s.ClientSet.AppsV1().DaemonSets(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取namespace" + namespace + "中的pod失败,错误信息" + err.Error()))
 		return nil, errors.New("获取namespace" + namespace + "中的pod失败,错误信息" + err.Error())
@@ -54,24 +60,19 @@ func (d *daemonSet) GetDaemonSets(filterName, namespace string, limit, page int)
 	// 将deploymentList中的deployment列表(Items)，放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: d.toCells(daemonSetList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{Name: filterName},
-			Paginate: &PaginateQuery{
-				limit,
-				page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(daemonSetList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的deployment列表转为appsv1.deployment列表
 	daemonSets := d.fromCells(data.GenericDataList)
 
 	return &DaemonSetsResp{
-		Items: daemonSets,
-		Total: total,
+		Items:    daemonSets,
+		Total:    total,
+		ListMeta: newListMeta(daemonSetList.ListMeta),
 	}, nil
 }
 
@@ -85,9 +86,14 @@ func (d *daemonSet) GetDaemonSetDetail(daemonSetName, namespace string) (daemonS
 	return daemonSet, nil
 }
 
-// CreateDaemonSets 创建DaemonSets，接受DeployCreate对象
+// CreateDaemonSets 创建DaemonSets，接受DaemonSetCreate对象
 func (d *daemonSet) CreateDaemonSets(data *DaemonSetCreate) (err error) {
-	// 将data中的数据组装成appsv1.Deployment对象
+	// 容器的端口、健康检查及资源配额与statefulSet使用相同的组装方式
+	container, err := newContainer(data.Name, data.Image, data.ContainerPort, data.Cpu, data.Memory, data.HealthCheck, data.HealthPath)
+	if err != nil {
+		return err
+	}
+	// 将data中的数据组装成appsv1.DaemonSet对象
 	daemonSet := &appsv1.DaemonSet{
 		// ObjectMeta中定义资源名、命名空间以及标签
 		ObjectMeta: metav1.ObjectMeta{
@@ -95,7 +101,7 @@ func (d *daemonSet) CreateDaemonSets(data *DaemonSetCreate) (err error) {
 			Namespace: data.Namespace,
 			Labels:    data.Label,
 		},
-		// Spec中定义副本数、选择器、以及pod属性
+		// Spec中定义选择器以及pod属性
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: data.Label,
@@ -106,76 +112,13 @@ func (d *daemonSet) CreateDaemonSets(data *DaemonSetCreate) (err error) {
 					Name:   data.Name,
 					Labels: data.Label,
 				},
-				// 定义容器名称，镜像和端口
 				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  data.Name,
-							Image: data.Image,
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "http",
-									Protocol:      corev1.ProtocolTCP,
-									ContainerPort: 80,
-								},
-							},
-						},
-					},
+					Containers: []corev1.Container{container},
 				},
 			},
 		},
-		// Status定义资源的运行状态，这里由于是新建，传入空的appsv1.DeployemntStatus{}对象即可
-		Status: appsv1.DaemonSetStatus{},
-	}
-	// 判断是否打开健康检查功能，若打开，则定义ReadinessProbe和LivenessProbe
-	if data.HealthCheck {
-		// 设置第一个容器的ReadinessProbe，因为我们pod中只有一个容器，所以直接使用index 0即可
-		// 若pod中有多个容器，则这里需要使用for循环去定义了
-		daemonSet.Spec.Template.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: data.HealthPath,
-					// intstr.IntOrString的作用是端口可以定义为整型，也可以定义为字符串
-					// Type=0则表示表示该结构体实例内的数据为整型，转json时只使用IntVal的数据
-					// Type=1则表示表示该结构体实例内的数据为字符串，转json时只使用StrVal的数据
-					Port: intstr.IntOrString{
-						Type:   0,
-						IntVal: data.ContainerPort,
-					},
-				},
-			},
-			// 初始化等待时间
-			InitialDelaySeconds: 15,
-			// 超时时间
-			TimeoutSeconds: 5,
-			// 执行时间
-			PeriodSeconds: 5,
-		}
-		daemonSet.Spec.Template.Spec.Containers[0].LivenessProbe = &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: data.HealthPath,
-					Port: intstr.IntOrString{
-						Type:   0,
-						IntVal: data.ContainerPort,
-					},
-				},
-			},
-			InitialDelaySeconds: 15,
-			TimeoutSeconds:      5,
-			PeriodSeconds:       5,
-		}
-		// 定义容器的limit和request资源
-		daemonSet.Spec.Template.Spec.Containers[0].Resources.Limits = map[corev1.ResourceName]resource.Quantity{
-			corev1.ResourceCPU:    resource.MustParse(data.Cpu),
-			corev1.ResourceMemory: resource.MustParse(data.Memory),
-		}
-		daemonSet.Spec.Template.Spec.Containers[0].Resources.Requests = map[corev1.ResourceName]resource.Quantity{
-			corev1.ResourceCPU:    resource.MustParse(data.Cpu),
-			corev1.ResourceMemory: resource.MustParse(data.Memory),
-		}
 	}
-	// 调用sdk创建deployment
+	// 调用sdk创建daemonSet
 	_, err = K8s.ClientSet.AppsV1().DaemonSets(data.Namespace).Create(context.TODO(), daemonSet, metav1.CreateOptions{})
 	if err != nil {
 		logger.Error(errors.New("创建daemonSet" + daemonSet.Name + "失败,错误信息 " + err.Error()))
@@ -194,6 +137,23 @@ func (d *daemonSet) DeleteDaemonSet(daemonSetName, namespace string) (err error)
 	return nil
 }
 
+// RestartDaemonSet 重启daemonset，与kubectl rollout restart一致，修改pod模板的restartedAt注解触发滚动更新，暂停中的daemonset需要先恢复
+func (d *daemonSet) RestartDaemonSet(daemonSetName, namespace string) (err error) {
+	if err := Rollout.checkRestartable("daemonset", daemonSetName, namespace); err != nil {
+		return err
+	}
+	patchByte, err := restartPatch()
+	if err != nil {
+		return err
+	}
+	_, err = K8s.ClientSet.AppsV1().DaemonSets(namespace).Patch(context.TODO(), daemonSetName, types.StrategicMergePatchType, patchByte, metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("重启daemonset " + daemonSetName + "失败,错误信息 " + err.Error()))
+		return errors.New("重启daemonset " + daemonSetName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
 // UpdateDaemonSet 更新daemonSet
 func (d *daemonSet) UpdateDaemonSet(namespace, content string) (err error) {
 	var daemonSet = &appsv1.DaemonSet{}
@@ -206,12 +166,30 @@ func (d *daemonSet) UpdateDaemonSet(namespace, content string) (err error) {
 
 	_, err = K8s.ClientSet.AppsV1().DaemonSets(namespace).Update(context.TODO(), daemonSet, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "DaemonSet", appsv1.SchemeGroupVersion.WithResource("daemonsets"), namespace, daemonSet); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新DaemonSet失败, " + err.Error()))
 		return errors.New("更新DaemonSet失败, " + err.Error())
 	}
 	return nil
 }
 
+// PatchDaemonSet 对DaemonSet打补丁，patchType支持json、merge、strategic，默认为merge
+func (d *daemonSet) PatchDaemonSet(daemonSetName, namespace, patchType, content string) (daemonSet *appsv1.DaemonSet, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	daemonSet, err = K8s.ClientSet.AppsV1().DaemonSets(namespace).Patch(context.TODO(), daemonSetName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改DaemonSet " + daemonSetName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改DaemonSet " + daemonSetName + "失败,错误信息 " + err.Error())
+	}
+	return daemonSet, nil
+}
+
 // 类型转换
 func (d *daemonSet) toCells(daemonSets []appsv1.DaemonSet) []DataCell {
 	cells := make([]DataCell, len(daemonSets))
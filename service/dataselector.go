@@ -1,10 +1,18 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	nwv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"sort" // 自定义类型排序参考文档：https://segmentfault.com/a/1190000008062661
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,23 +29,194 @@ type DataCell interface {
 	GetName() string
 }
 
-// DataSelectQuery 定义过滤和分页的属性，过滤:Name， 分页:Limit和Page
+// MetaCell 可选接口，实现后支持按标签选择器、namespace、owner过滤以及按namespace排序
+type MetaCell interface {
+	GetObjectMeta() metav1.Object
+}
+
+// FieldCell 可选接口，实现后支持按资源特有的字段过滤，如pod的phase、node
+type FieldCell interface {
+	GetField(field string) (string, bool)
+}
+
+// SortCell 可选接口，实现后支持按资源特有的数值属性排序，如pod的restarts、cpu
+type SortCell interface {
+	GetSortValue(property string) (int64, bool)
+}
+
+// DataSelectQuery 定义过滤、排序和分页的属性，过滤:Name、标签和字段， 排序:SortBy， 分页:Limit和Page
 // Limit是单页的数据条数
 // Page是第几页
 type DataSelectQuery struct {
 	Filter   *FilterQuery
 	Paginate *PaginateQuery
+	Sort     *SortQuery
 }
 
 // FilterQuery 过滤查询
+// LabelSelector使用k8s标签选择器语法，如 app=nginx,tier!=db
+// Fields为字段过滤条件，支持namespace、owner以及资源特有的字段(如phase、node)
 type FilterQuery struct {
-	Name string
+	Name          string
+	LabelSelector labels.Selector
+	Fields        []FieldFilter
+}
+
+// FieldFilter 字段过滤条件，Negate为true时表示不等于
+type FieldFilter struct {
+	Field  string
+	Value  string
+	Negate bool
+}
+
+// SortQuery 排序查询，按SortBy中的顺序依次比较，为空时按创建时间倒序
+type SortQuery struct {
+	SortBy []SortField
+}
+
+// SortField 排序字段，Property为排序属性，Ascending为true时升序
+type SortField struct {
+	Property  string
+	Ascending bool
+}
+
+// SelectParams 定义列表接口通用的标签、字段过滤、排序以及分页模式参数，由controller从query参数中绑定
+// label_selector示例: app=nginx,tier!=db
+// field_selector示例: phase=Running,node=node-1,owner=nginx-5d9c,namespace=default
+// sort_by示例: -restarts,name 表示先按重启次数倒序，再按名称升序
+type SelectParams struct {
+	LabelSelector string `form:"label_selector"`
+	FieldSelector string `form:"field_selector"`
+	SortBy        string `form:"sort_by"`
+	// PaginateMode为server时使用apiserver分页，Continue为上一页返回的continue token
+	PaginateMode string `form:"paginate_mode"`
+	Continue     string `form:"continue"`
+}
+
+// 支持的排序属性
+var sortProperties = map[string]bool{
+	"name":      true,
+	"creation":  true,
+	"namespace": true,
+	"restarts":  true,
+	"cpu":       true,
+}
+
+// newDataSelectQuery 将列表接口的入参组装成DataSelectQuery，params可以为nil
+func newDataSelectQuery(filterName string, limit, page int, params *SelectParams) (*DataSelectQuery, error) {
+	query := &DataSelectQuery{
+		Filter:   &FilterQuery{Name: filterName},
+		Paginate: &PaginateQuery{Limit: limit, Page: page},
+		Sort:     &SortQuery{},
+	}
+	if params == nil {
+		return query, nil
+	}
+	// 解析标签选择器
+	if params.LabelSelector != "" {
+		selector, err := labels.Parse(params.LabelSelector)
+		if err != nil {
+			return nil, errors.New("标签选择器 " + params.LabelSelector + "格式错误, " + err.Error())
+		}
+		query.Filter.LabelSelector = selector
+	}
+	// 解析字段过滤条件
+	for _, term := range strings.Split(params.FieldSelector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		filter := FieldFilter{}
+		var parts []string
+		switch {
+		case strings.Contains(term, "!="):
+			parts = strings.SplitN(term, "!=", 2)
+			filter.Negate = true
+		case strings.Contains(term, "=="):
+			parts = strings.SplitN(term, "==", 2)
+		case strings.Contains(term, "="):
+			parts = strings.SplitN(term, "=", 2)
+		default:
+			return nil, errors.New("字段过滤条件 " + term + "格式错误，应为key=value或key!=value")
+		}
+		filter.Field = strings.TrimSpace(parts[0])
+		filter.Value = strings.TrimSpace(parts[1])
+		if filter.Field == "" {
+			return nil, errors.New("字段过滤条件 " + term + "格式错误，字段名不能为空")
+		}
+		query.Filter.Fields = append(query.Filter.Fields, filter)
+	}
+	// 解析排序字段，"-"前缀表示倒序
+	for _, property := range strings.Split(params.SortBy, ",") {
+		property = strings.TrimSpace(property)
+		if property == "" {
+			continue
+		}
+		field := SortField{Ascending: true}
+		if strings.HasPrefix(property, "-") {
+			field.Ascending = false
+			property = property[1:]
+		} else if strings.HasPrefix(property, "+") {
+			property = property[1:]
+		}
+		if !sortProperties[property] {
+			return nil, errors.New("不支持的排序属性 " + property + "，可选值为name、creation、namespace、restarts、cpu")
+		}
+		field.Property = property
+		query.Sort.SortBy = append(query.Sort.SortBy, field)
+	}
+	// apiserver分页只能按apiserver的顺序返回、只支持标签选择器过滤
+	// 需要按名称模糊过滤、字段过滤或自定义排序时，必须获取全量数据，回退到内存分页
+	if params.PaginateMode == "server" || params.Continue != "" {
+		if limit <= 0 {
+			return nil, errors.New("apiserver分页模式下limit必须大于0")
+		}
+		if filterName == "" && len(query.Filter.Fields) == 0 && len(query.Sort.SortBy) == 0 {
+			query.Paginate.Server = true
+			query.Paginate.Continue = params.Continue
+		}
+	}
+	return query, nil
 }
 
 // PaginateQuery 分页查询
+// Server为true时使用apiserver分页，Limit和Continue通过metav1.ListOptions传给apiserver，Page不再生效
 type PaginateQuery struct {
-	Limit int
-	Page  int
+	Limit    int
+	Page     int
+	Server   bool
+	Continue string
+}
+
+// ListMeta 定义apiserver分页时返回的continue token和剩余数量，内存分页时为空
+// 嵌入到各资源的列表返回结构体中，客户端携带Continue请求下一页
+type ListMeta struct {
+	Continue           string `json:"continue,omitempty"`
+	RemainingItemCount *int64 `json:"remainingItemCount,omitempty"`
+}
+
+// ListOptions 根据查询条件组装metav1.ListOptions
+// 使用apiserver分页时传入Limit、Continue以及标签选择器，否则获取全量数据
+func (q *DataSelectQuery) ListOptions() metav1.ListOptions {
+	if !q.Paginate.Server {
+		return metav1.ListOptions{}
+	}
+	options := metav1.ListOptions{
+		Limit:    int64(q.Paginate.Limit),
+		Continue: q.Paginate.Continue,
+	}
+	if q.Filter.LabelSelector != nil {
+		options.LabelSelector = q.Filter.LabelSelector.String()
+	}
+	return options
+}
+
+// newListMeta 将apiserver返回的分页信息转换为ListMeta
+func newListMeta(listMeta metav1.ListMeta) ListMeta {
+	return ListMeta{
+		Continue:           listMeta.Continue,
+		RemainingItemCount: listMeta.RemainingItemCount,
+	}
 }
 
 // 定义deployment结构体
@@ -53,6 +232,19 @@ func (d deploymentCell) GetName() string {
 	return d.Name
 }
 
+// GetObjectMeta 获取deployment的元数据
+func (d deploymentCell) GetObjectMeta() metav1.Object {
+	return &d.ObjectMeta
+}
+
+// GetSortValue 获取deployment的数值排序属性，cpu为pod模板中的cpu request(毫核)
+func (d deploymentCell) GetSortValue(property string) (int64, bool) {
+	if property == "cpu" {
+		return podSpecCpuRequest(d.Spec.Template.Spec), true
+	}
+	return 0, false
+}
+
 //实现自定义结构的排序，需要重写Len、Swap、Less方法
 
 // Len 方法用于获取数组长度
@@ -71,45 +263,174 @@ func (d *dataSelector) Swap(i, j int) {
 }
 
 // Less 方法用于定义数组中元素排序的“大小”的比较方式
+// 未指定排序字段时按创建时间倒序，否则按SortBy依次比较，前一个字段相等时才比较下一个
 func (d *dataSelector) Less(i, j int) bool {
-	a := d.GenericDataList[i].GetCreation()
-	b := d.GenericDataList[j].GetCreation()
-	return b.Before(a)
+	if d.DataSelectQuery.Sort == nil || len(d.DataSelectQuery.Sort.SortBy) == 0 {
+		a := d.GenericDataList[i].GetCreation()
+		b := d.GenericDataList[j].GetCreation()
+		return b.Before(a)
+	}
+	for _, field := range d.DataSelectQuery.Sort.SortBy {
+		result := compareProperty(d.GenericDataList[i], d.GenericDataList[j], field.Property)
+		if result == 0 {
+			continue
+		}
+		if field.Ascending {
+			return result < 0
+		}
+		return result > 0
+	}
+	return false
+}
+
+// compareProperty 比较两个元素的属性，a<b返回-1，a=b返回0，a>b返回1
+func compareProperty(a, b DataCell, property string) int {
+	switch property {
+	case "name":
+		return strings.Compare(a.GetName(), b.GetName())
+	case "creation":
+		ta, tb := a.GetCreation(), b.GetCreation()
+		if ta.Before(tb) {
+			return -1
+		}
+		if tb.Before(ta) {
+			return 1
+		}
+		return 0
+	case "namespace":
+		return strings.Compare(cellNamespace(a), cellNamespace(b))
+	default:
+		va, vb := cellSortValue(a, property), cellSortValue(b, property)
+		if va < vb {
+			return -1
+		}
+		if va > vb {
+			return 1
+		}
+		return 0
+	}
+}
+
+// cellNamespace 获取元素的namespace，未实现MetaCell时返回空字符串
+func cellNamespace(cell DataCell) string {
+	if metaCell, ok := cell.(MetaCell); ok {
+		return metaCell.GetObjectMeta().GetNamespace()
+	}
+	return ""
+}
+
+// cellSortValue 获取元素的数值排序属性，不支持时返回0
+func cellSortValue(cell DataCell, property string) int64 {
+	if sortCell, ok := cell.(SortCell); ok {
+		if value, ok := sortCell.GetSortValue(property); ok {
+			return value
+		}
+	}
+	return 0
 }
 
 // Sort 重写以上3个方法用使用sort.Sort进行排序
+// 使用apiserver分页时保持apiserver返回的顺序，避免每页各自排序导致顺序混乱
 func (d *dataSelector) Sort() *dataSelector {
+	if d.DataSelectQuery.Paginate.Server {
+		return d
+	}
 	sort.Sort(d)
 	return d
 }
 
-// Filter 方法用于过滤元素，比较元素的Name属性，若包含，再返回
+// Filter 方法用于过滤元素，比较元素的Name属性、标签以及字段，全部匹配时再返回
 func (d *dataSelector) Filter() *dataSelector {
+	filter := d.DataSelectQuery.Filter
 	// 判断入参是否为空，若为空，则返回所有数据
-	if d.DataSelectQuery.Filter.Name == "" {
+	if filter.Name == "" && filter.LabelSelector == nil && len(filter.Fields) == 0 {
 		return d
 	}
-	// 若入参的传参不为空，则返回元素名中包含Name的所有元素
-	// 声明一个新的数组,若Name包含,则把数据放进数组，返回出去
+	// 若入参的传参不为空，则返回元素名中包含Name，且标签和字段均匹配的所有元素
+	// 声明一个新的数组,若匹配,则把数据放进数组，返回出去
 	var filtered []DataCell
 	for _, value := range d.GenericDataList {
-		// 定义是否匹配标签变量，默认匹配
-		matches := true
 		objName := value.GetName()
-		if !strings.Contains(objName, d.DataSelectQuery.Filter.Name) {
-			matches = false
+		if !strings.Contains(objName, filter.Name) {
 			continue
 		}
-		if matches {
-			filtered = append(filtered, value)
+		if !filter.matchLabels(value) || !filter.matchFields(value) {
+			continue
 		}
+		filtered = append(filtered, value)
 	}
 	d.GenericDataList = filtered
 	return d
 }
 
+// matchLabels 判断元素的标签是否匹配标签选择器，未实现MetaCell的元素不匹配非空选择器
+func (f *FilterQuery) matchLabels(cell DataCell) bool {
+	if f.LabelSelector == nil || f.LabelSelector.Empty() {
+		return true
+	}
+	metaCell, ok := cell.(MetaCell)
+	if !ok {
+		return false
+	}
+	return f.LabelSelector.Matches(labels.Set(metaCell.GetObjectMeta().GetLabels()))
+}
+
+// matchFields 判断元素是否匹配所有字段过滤条件
+func (f *FilterQuery) matchFields(cell DataCell) bool {
+	for _, filter := range f.Fields {
+		if matchField(cell, filter.Field, filter.Value) == filter.Negate {
+			return false
+		}
+	}
+	return true
+}
+
+// matchField 判断元素的单个字段是否等于value
+// namespace和owner为通用字段，owner可以是owner的名称或Kind/名称，其余字段由FieldCell提供
+func matchField(cell DataCell, field, value string) bool {
+	switch field {
+	case "name":
+		return cell.GetName() == value
+	case "namespace":
+		return cellNamespace(cell) == value
+	case "owner":
+		metaCell, ok := cell.(MetaCell)
+		if !ok {
+			return false
+		}
+		for _, owner := range metaCell.GetObjectMeta().GetOwnerReferences() {
+			if owner.Name == value || strings.EqualFold(owner.Kind+"/"+owner.Name, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		fieldCell, ok := cell.(FieldCell)
+		if !ok {
+			return false
+		}
+		actual, ok := fieldCell.GetField(field)
+		return ok && strings.EqualFold(actual, value)
+	}
+}
+
+// Total 返回过滤后的数据总数，需要在Paginate之前调用
+// 使用apiserver分页时，当前只有一页数据，总数为本页数量加上apiserver返回的remainingItemCount；
+// apiserver未返回remainingItemCount时(例如使用了标签选择器)无法得知总数，只返回本页数量，客户端应以continue是否为空判断是否还有下一页
+func (d *dataSelector) Total(remainingItemCount *int64) int {
+	total := len(d.GenericDataList)
+	if d.DataSelectQuery.Paginate.Server && remainingItemCount != nil {
+		total += int(*remainingItemCount)
+	}
+	return total
+}
+
 // Paginate 方法用于数据分页，根据limit和page的传参，取一定范围内的数据，返回
+// 使用apiserver分页时，数据已经是单页内容，直接返回
 func (d *dataSelector) Paginate() *dataSelector {
+	if d.DataSelectQuery.Paginate.Server {
+		return d
+	}
 	// 根据Limit和Page的入参，定义快捷变量
 	limit := d.DataSelectQuery.Paginate.Limit
 	page := d.DataSelectQuery.Paginate.Page
@@ -117,14 +438,18 @@ func (d *dataSelector) Paginate() *dataSelector {
 	if limit <= 0 || page <= 0 {
 		return d
 	}
-	// 定义取值范围需要的startIndex和endIndex
-	// 举例,有25个元素的数组，limit是10， page是3，startIndex是20，endIndex是30（endIndex是24）
+	// 定义取值范围需要的startIndex和endIndex，切片取值不包含endIndex
+	// 举例,有25个元素的数组，limit是10， page是3，startIndex是20，endIndex是30（endIndex处理后是25）
 	startIndex := limit * (page - 1)
-	endIndex := limit*page - 1
+	endIndex := limit * page
 
-	// 处理endIndex
+	// 处理startIndex和endIndex
+	if startIndex >= len(d.GenericDataList) {
+		d.GenericDataList = []DataCell{}
+		return d
+	}
 	if endIndex > len(d.GenericDataList) {
-		endIndex = len(d.GenericDataList) - 1
+		endIndex = len(d.GenericDataList)
 	}
 
 	d.GenericDataList = d.GenericDataList[startIndex:endIndex]
@@ -145,6 +470,34 @@ func (p podCell) GetName() string {
 	return p.Name
 }
 
+func (p podCell) GetObjectMeta() metav1.Object {
+	return &p.ObjectMeta
+}
+
+// GetField 获取pod的过滤字段，phase为运行阶段，node为所在节点
+func (p podCell) GetField(field string) (string, bool) {
+	switch field {
+	case "phase", "status":
+		return string(p.Status.Phase), true
+	case "node":
+		return p.Spec.NodeName, true
+	case "ip":
+		return p.Status.PodIP, true
+	}
+	return "", false
+}
+
+// GetSortValue 获取pod的数值排序属性，restarts为容器重启次数之和，cpu为cpu request之和(毫核)
+func (p podCell) GetSortValue(property string) (int64, bool) {
+	switch property {
+	case "restarts":
+		return int64(podRestarts(p.Status)), true
+	case "cpu":
+		return podSpecCpuRequest(p.Spec), true
+	}
+	return 0, false
+}
+
 /* daemonSet相关配置 */
 type daemonSetCell appsv1.DaemonSet
 
@@ -156,6 +509,17 @@ func (d daemonSetCell) GetName() string {
 	return d.Name
 }
 
+func (d daemonSetCell) GetObjectMeta() metav1.Object {
+	return &d.ObjectMeta
+}
+
+func (d daemonSetCell) GetSortValue(property string) (int64, bool) {
+	if property == "cpu" {
+		return podSpecCpuRequest(d.Spec.Template.Spec), true
+	}
+	return 0, false
+}
+
 /* statefulSet相关配置 */
 type statefulSetCell appsv1.StatefulSet
 
@@ -167,6 +531,17 @@ func (s statefulSetCell) GetName() string {
 	return s.Name
 }
 
+func (s statefulSetCell) GetObjectMeta() metav1.Object {
+	return &s.ObjectMeta
+}
+
+func (s statefulSetCell) GetSortValue(property string) (int64, bool) {
+	if property == "cpu" {
+		return podSpecCpuRequest(s.Spec.Template.Spec), true
+	}
+	return 0, false
+}
+
 /* service相关配置 */
 type serviceCell corev1.Service
 
@@ -178,6 +553,17 @@ func (s serviceCell) GetName() string {
 	return s.Name
 }
 
+func (s serviceCell) GetObjectMeta() metav1.Object {
+	return &s.ObjectMeta
+}
+
+func (s serviceCell) GetField(field string) (string, bool) {
+	if field == "type" {
+		return string(s.Spec.Type), true
+	}
+	return "", false
+}
+
 /* ingress相关配置 */
 type ingressCell nwv1.Ingress
 
@@ -189,6 +575,10 @@ func (i ingressCell) GetName() string {
 	return i.Name
 }
 
+func (i ingressCell) GetObjectMeta() metav1.Object {
+	return &i.ObjectMeta
+}
+
 /* configMap相关配置 */
 type configMapCell corev1.ConfigMap
 
@@ -200,6 +590,10 @@ func (c configMapCell) GetName() string {
 	return c.Name
 }
 
+func (c configMapCell) GetObjectMeta() metav1.Object {
+	return &c.ObjectMeta
+}
+
 /* secret相关配置 */
 type secretCell corev1.Secret
 
@@ -211,6 +605,17 @@ func (s secretCell) GetName() string {
 	return s.Name
 }
 
+func (s secretCell) GetObjectMeta() metav1.Object {
+	return &s.ObjectMeta
+}
+
+func (s secretCell) GetField(field string) (string, bool) {
+	if field == "type" {
+		return string(s.Type), true
+	}
+	return "", false
+}
+
 /* node相关配置 */
 type nodeCell corev1.Node
 
@@ -222,6 +627,10 @@ func (n nodeCell) GetName() string {
 	return n.Name
 }
 
+func (n nodeCell) GetObjectMeta() metav1.Object {
+	return &n.ObjectMeta
+}
+
 /* namespace相关配置 */
 type namespaceCell corev1.Namespace
 
@@ -233,6 +642,17 @@ func (n namespaceCell) GetName() string {
 	return n.Name
 }
 
+func (n namespaceCell) GetObjectMeta() metav1.Object {
+	return &n.ObjectMeta
+}
+
+func (n namespaceCell) GetField(field string) (string, bool) {
+	if field == "phase" || field == "status" {
+		return string(n.Status.Phase), true
+	}
+	return "", false
+}
+
 /* pv相关配置 */
 type pvCell corev1.PersistentVolume
 
@@ -244,6 +664,20 @@ func (p pvCell) GetName() string {
 	return p.Name
 }
 
+func (p pvCell) GetObjectMeta() metav1.Object {
+	return &p.ObjectMeta
+}
+
+func (p pvCell) GetField(field string) (string, bool) {
+	switch field {
+	case "phase", "status":
+		return string(p.Status.Phase), true
+	case "storageclass":
+		return p.Spec.StorageClassName, true
+	}
+	return "", false
+}
+
 /* pvc相关配置 */
 type pvcCell corev1.PersistentVolumeClaim
 
@@ -254,3 +688,187 @@ func (p pvcCell) GetCreation() time.Time {
 func (p pvcCell) GetName() string {
 	return p.Name
 }
+
+func (p pvcCell) GetObjectMeta() metav1.Object {
+	return &p.ObjectMeta
+}
+
+func (p pvcCell) GetField(field string) (string, bool) {
+	switch field {
+	case "phase", "status":
+		return string(p.Status.Phase), true
+	case "storageclass":
+		if p.Spec.StorageClassName != nil {
+			return *p.Spec.StorageClassName, true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+/* job相关配置 */
+type jobCell batchv1.Job
+
+func (j jobCell) GetCreation() time.Time {
+	return j.CreationTimestamp.Time
+}
+
+func (j jobCell) GetName() string {
+	return j.Name
+}
+
+func (j jobCell) GetObjectMeta() metav1.Object {
+	return &j.ObjectMeta
+}
+
+func (j jobCell) GetField(field string) (string, bool) {
+	if field == "status" {
+		job := batchv1.Job(j)
+		status, _ := jobStatusOf(&job)
+		return status, true
+	}
+	return "", false
+}
+
+func (j jobCell) GetSortValue(property string) (int64, bool) {
+	if property == "cpu" {
+		return podSpecCpuRequest(j.Spec.Template.Spec), true
+	}
+	return 0, false
+}
+
+/* cronJob相关配置 */
+type cronJobCell batchv1.CronJob
+
+func (c cronJobCell) GetCreation() time.Time {
+	return c.CreationTimestamp.Time
+}
+
+func (c cronJobCell) GetName() string {
+	return c.Name
+}
+
+func (c cronJobCell) GetObjectMeta() metav1.Object {
+	return &c.ObjectMeta
+}
+
+func (c cronJobCell) GetField(field string) (string, bool) {
+	switch field {
+	case "suspend":
+		return strconv.FormatBool(c.Spec.Suspend != nil && *c.Spec.Suspend), true
+	case "schedule":
+		return c.Spec.Schedule, true
+	}
+	return "", false
+}
+
+/* hpa相关配置 */
+type hpaCell autoscalingv2.HorizontalPodAutoscaler
+
+func (h hpaCell) GetCreation() time.Time {
+	return h.CreationTimestamp.Time
+}
+
+func (h hpaCell) GetName() string {
+	return h.Name
+}
+
+func (h hpaCell) GetObjectMeta() metav1.Object {
+	return &h.ObjectMeta
+}
+
+func (h hpaCell) GetField(field string) (string, bool) {
+	if field == "target" {
+		return h.Spec.ScaleTargetRef.Kind + "/" + h.Spec.ScaleTargetRef.Name, true
+	}
+	return "", false
+}
+
+/* 通用资源相关配置 */
+// unstructuredCell 用于dynamic client返回的任意资源，使新的资源类型无需编写cell即可使用dataSelector
+type unstructuredCell unstructured.Unstructured
+
+func (u unstructuredCell) GetCreation() time.Time {
+	obj := unstructured.Unstructured(u)
+	return obj.GetCreationTimestamp().Time
+}
+
+func (u unstructuredCell) GetName() string {
+	obj := unstructured.Unstructured(u)
+	return obj.GetName()
+}
+
+func (u unstructuredCell) GetObjectMeta() metav1.Object {
+	obj := unstructured.Unstructured(u)
+	return &obj
+}
+
+// GetField 支持phase、node等常用字段，其余字段按点分隔的路径读取，如spec.type、status.phase
+func (u unstructuredCell) GetField(field string) (string, bool) {
+	path := strings.Split(field, ".")
+	switch field {
+	case "phase", "status":
+		path = []string{"status", "phase"}
+	case "node":
+		path = []string{"spec", "nodeName"}
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(u.Object, path...)
+	if err != nil || !found {
+		return "", false
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+/* server-side table相关配置 */
+// tableRowCell 用于apiserver返回的Table行，metadata来自includeObject=Metadata返回的对象
+type tableRowCell struct {
+	row     metav1.TableRow
+	meta    *metav1.PartialObjectMetadata
+	columns []metav1.TableColumnDefinition
+}
+
+func (t tableRowCell) GetCreation() time.Time {
+	return t.meta.CreationTimestamp.Time
+}
+
+func (t tableRowCell) GetName() string {
+	return t.meta.Name
+}
+
+func (t tableRowCell) GetObjectMeta() metav1.Object {
+	return &t.meta.ObjectMeta
+}
+
+// GetField 按列名过滤，列名不区分大小写，如status、ready
+func (t tableRowCell) GetField(field string) (string, bool) {
+	for i, column := range t.columns {
+		if strings.EqualFold(column.Name, field) && i < len(t.row.Cells) {
+			return fmt.Sprint(t.row.Cells[i]), true
+		}
+	}
+	return "", false
+}
+
+// podRestarts 计算pod中所有容器的重启次数之和
+func podRestarts(status corev1.PodStatus) int32 {
+	var restarts int32
+	for _, containerStatus := range status.ContainerStatuses {
+		restarts += containerStatus.RestartCount
+	}
+	return restarts
+}
+
+// podSpecCpuRequest 计算pod中所有容器的cpu request之和，单位为毫核
+func podSpecCpuRequest(spec corev1.PodSpec) int64 {
+	var cpu int64
+	for _, container := range spec.Containers {
+		if request, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpu += request.MilliValue()
+		}
+	}
+	return cpu
+}
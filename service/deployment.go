@@ -4,15 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/wonderivan/logger"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
-	"strconv"
-	"time"
 )
 
 // Deployment 实例化deployment结构体
@@ -25,6 +20,7 @@ type deployment struct{}
 type DeploymentsResp struct {
 	Items []appsv1.Deployment `json:"items"`
 	Total int                 `json:"total"`
+	ListMeta
 }
 
 // DeployCreate 定义DeployCreate结构体，用于创建deployment需要的参数属性的定义
@@ -48,9 +44,15 @@ type DeployNp struct {
 }
 
 // GetDeployments 获取deployment列表，支持过滤、排序、分页
-func (d *deployment) GetDeployments(filterName, namespace string, limit, page int) (deploymentsResp *DeploymentsResp, err error) {
+func (d *deployment) GetDeployments(filterName, namespace string, limit, page int, selectParams *SelectParams) (deploymentsResp *DeploymentsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取deploymentList类型的deployment列表
-	deploymentList, err := K8s.ClientSet.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deploymentList, err := K8s.ClientSet.AppsV1().Deployments(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取namespace" + namespace + "中的pod失败,错误信息" + err.Error()))
 		return nil, errors.New("获取namespace" + namespace + "中的pod失败,错误信息" + err.Error())
@@ -58,24 +60,19 @@ func (d *deployment) GetDeployments(filterName, namespace string, limit, page in
 	// 将deploymentList中的deployment列表(Items)，放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: d.toCells(deploymentList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{Name: filterName},
-			Paginate: &PaginateQuery{
-				limit,
-				page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(deploymentList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的deployment列表转为appsv1.deployment列表
 	deployments := d.fromCells(data.GenericDataList)
 
 	return &DeploymentsResp{
-		Items: deployments,
-		Total: total,
+		Items:    deployments,
+		Total:    total,
+		ListMeta: newListMeta(deploymentList.ListMeta),
 	}, nil
 }
 
@@ -110,6 +107,11 @@ func (d *deployment) ScaleDeployment(deploymentName, namespace string, scaleNum
 
 // CreateDeployment 创建deployment，接受DeployCreate对象
 func (d *deployment) CreateDeployment(data *DeployCreate) (err error) {
+	// 组装容器，打开健康检查功能时同时定义ReadinessProbe、LivenessProbe以及limit和request资源
+	container, err := newContainer(data.Name, data.Image, data.ContainerPort, data.Cpu, data.Memory, data.HealthCheck, data.HealthPath)
+	if err != nil {
+		return err
+	}
 	// 将data中的数据组装成appsv1.Deployment对象
 	deploy := &appsv1.Deployment{
 		// ObjectMeta中定义资源名、命名空间以及标签
@@ -130,75 +132,14 @@ func (d *deployment) CreateDeployment(data *DeployCreate) (err error) {
 					Name:   data.Name,
 					Labels: data.Label,
 				},
-				// 定义容器名称，镜像和端口
 				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  data.Name,
-							Image: data.Image,
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "http",
-									Protocol:      corev1.ProtocolTCP,
-									ContainerPort: 80,
-								},
-							},
-						},
-					},
+					Containers: []corev1.Container{container},
 				},
 			},
 		},
 		// Status定义资源的运行状态，这里由于是新建，传入空的appsv1.DeployemntStatus{}对象即可
 		Status: appsv1.DeploymentStatus{},
 	}
-	// 判断是否打开健康检查功能，若打开，则定义ReadinessProbe和LivenessProbe
-	if data.HealthCheck {
-		// 设置第一个容器的ReadinessProbe，因为我们pod中只有一个容器，所以直接使用index 0即可
-		// 若pod中有多个容器，则这里需要使用for循环去定义了
-		deploy.Spec.Template.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: data.HealthPath,
-					// intstr.IntOrString的作用是端口可以定义为整型，也可以定义为字符串
-					// Type=0则表示表示该结构体实例内的数据为整型，转json时只使用IntVal的数据
-					// Type=1则表示表示该结构体实例内的数据为字符串，转json时只使用StrVal的数据
-					Port: intstr.IntOrString{
-						Type:   0,
-						IntVal: data.ContainerPort,
-					},
-				},
-			},
-			// 初始化等待时间
-			InitialDelaySeconds: 15,
-			// 超时时间
-			TimeoutSeconds: 5,
-			// 执行时间
-			PeriodSeconds: 5,
-		}
-		deploy.Spec.Template.Spec.Containers[0].LivenessProbe = &corev1.Probe{
-			ProbeHandler: corev1.ProbeHandler{
-				HTTPGet: &corev1.HTTPGetAction{
-					Path: data.HealthPath,
-					Port: intstr.IntOrString{
-						Type:   0,
-						IntVal: data.ContainerPort,
-					},
-				},
-			},
-			InitialDelaySeconds: 15,
-			TimeoutSeconds:      5,
-			PeriodSeconds:       5,
-		}
-		// 定义容器的limit和request资源
-		deploy.Spec.Template.Spec.Containers[0].Resources.Limits = map[corev1.ResourceName]resource.Quantity{
-			corev1.ResourceCPU:    resource.MustParse(data.Cpu),
-			corev1.ResourceMemory: resource.MustParse(data.Memory),
-		}
-		deploy.Spec.Template.Spec.Containers[0].Resources.Requests = map[corev1.ResourceName]resource.Quantity{
-			corev1.ResourceCPU:    resource.MustParse(data.Cpu),
-			corev1.ResourceMemory: resource.MustParse(data.Memory),
-		}
-	}
 	// 调用sdk创建deployment
 	_, err = K8s.ClientSet.AppsV1().Deployments(data.Namespace).Create(context.TODO(), deploy, metav1.CreateOptions{})
 	if err != nil {
@@ -220,34 +161,19 @@ func (d *deployment) DeleteDeployment(deploymentName, namespace string) (err err
 
 // RestartDeployment 重启deployment
 func (d *deployment) RestartDeployment(deploymentName, namespace string) (err error) {
-	// 此功能等同于一个kubectl命令
-	// kubectl deployment ${service} -p {"spec":{"template":{"spec":{"containers":[{"name":"'"${service}"'","env": [{"name":"RESTART_","value":"'$(date +%s)'"}]}]}}}}'
-
-	// 使用pathData Map组装数据
-	patchData := map[string]interface{}{
-		"spec": map[string]interface{}{
-			"template": map[string]interface{}{
-				"spec": map[string]interface{}{
-					"containers": []map[string]interface{}{
-						{
-							"name": deploymentName,
-							"env": []map[string]string{
-								{
-									"name":  "RESTART_",
-									"value": strconv.FormatInt(time.Now().Unix(), 10),
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+	// 此功能等同于kubectl rollout restart deployment ${service}
+	// 修改pod模板的kubectl.kubernetes.io/restartedAt注解触发滚动更新，暂停中的deployment不会更新，需要先恢复
+	deployment, err := d.GetDeploymentDetail(deploymentName, namespace)
+	if err != nil {
+		return err
 	}
-	// 序列化字节，因为patch方法只接受节点类型参数
-	patchByte, err := json.Marshal(patchData)
+	if deployment.Spec.Paused {
+		logger.Error(errors.New("deployment " + deploymentName + "已暂停,请恢复后再重启"))
+		return errors.New("deployment " + deploymentName + "已暂停,请恢复后再重启")
+	}
+	patchByte, err := restartPatch()
 	if err != nil {
-		logger.Error(errors.New("json序列化数据失败，错误信息 " + err.Error()))
-		return errors.New("json序列化数据失败，错误信息 " + err.Error())
+		return err
 	}
 
 	// 调用patch方法更新deployment
@@ -271,32 +197,28 @@ func (d *deployment) UpdateDeployment(namespace, content string) (err error) {
 
 	_, err = K8s.ClientSet.AppsV1().Deployments(namespace).Update(context.TODO(), deploy, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "Deployment", appsv1.SchemeGroupVersion.WithResource("deployments"), namespace, deploy); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新deployment失败,错误信息 " + err.Error()))
 		return errors.New("更新deployment失败,错误信息 " + err.Error())
 	}
 	return nil
 }
 
-// GetDeployReplicaSets 获取deployment的历史版本信息
-func (d *deployment) GetDeployReplicaSets(deploymentName, namespace string) (err error) {
-	labelSelector := fmt.Sprintf("app=%s", deploymentName)
-	fmt.Println(labelSelector, deploymentName)
-	// 获取replicaSetList列表
-	replicaSetList, err := K8s.ClientSet.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+// PatchDeployment 对deployment打补丁，patchType支持json、merge、strategic，默认为merge
+func (d *deployment) PatchDeployment(deploymentName, namespace, patchType, content string) (deploy *appsv1.Deployment, err error) {
+	pt, err := parsePatchType(patchType)
 	if err != nil {
-		logger.Error(errors.New("获取namespace " + namespace + "下deployment " + deploymentName + "历史版本信息失败,错误信息," + err.Error()))
-		return errors.New("获取namespace " + namespace + "下deployment " + deploymentName + "历史版本信息失败,错误信息," + err.Error())
+		logger.Error(err)
+		return nil, err
 	}
-
-	if len(replicaSetList.Items) <= 1 {
-		logger.Info("deployment " + deploymentName + "未有可回滚版本")
-		return errors.New("deployment " + deploymentName + "未有可回滚版本")
-	} else {
-		for item := range replicaSetList.Items {
-			fmt.Println(item)
-		}
+	deploy, err = K8s.ClientSet.AppsV1().Deployments(namespace).Patch(context.TODO(), deploymentName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改deployment " + deploymentName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改deployment " + deploymentName + "失败,错误信息 " + err.Error())
 	}
-	return nil
+	return deploy, nil
 }
 
 // GetDeployNumPerNp 获取每个namespace中的的deployment数量
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// describe输出，与kubectl describe类似，将资源及其关联的事件、状态条件、卷等整理为分段的内容
+// 同时返回结构化的分段(Sections)和渲染后的文本(Text)
+
+// Describe 定义Describe全局变量
+var Describe describe
+
+// 定义describe结构体
+type describe struct{}
+
+// DescribeResult 定义describe的返回内容
+type DescribeResult struct {
+	Kind      string             `json:"kind"`
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace"`
+	Sections  []*DescribeSection `json:"sections"`
+	Text      string             `json:"text"`
+}
+
+// DescribeSection 定义describe中的一个分段，Title为空的分段为资源的基本信息
+// 分段可以包含字段、表格以及子分段(如pod中的每个容器)
+type DescribeSection struct {
+	Title    string             `json:"title"`
+	Fields   []DescribeField    `json:"fields,omitempty"`
+	Table    *DescribeTable     `json:"table,omitempty"`
+	Sections []*DescribeSection `json:"sections,omitempty"`
+}
+
+// DescribeField 定义分段中的字段
+type DescribeField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DescribeTable 定义分段中的表格
+type DescribeTable struct {
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+// describer 生成某种资源的describe分段
+type describer func(result *DescribeResult, name, namespace string) error
+
+// describers 支持describe的资源类型
+var describers = map[string]describer{
+	"pod":         describePod,
+	"deployment":  describeDeployment,
+	"statefulset": describeStatefulSet,
+	"daemonset":   describeDaemonSet,
+	"service":     describeService,
+	"ingress":     describeIngress,
+	"node":        describeNode,
+	"pv":          describePv,
+	"pvc":         describePvc,
+}
+
+// Describe 获取资源的describe内容，kind不区分大小写，可选值为pod、deployment、statefulset、daemonset、
+// service、ingress、node、pv、pvc，集群级别的资源忽略namespace
+func (d *describe) Describe(kind, name, namespace string) (result *DescribeResult, err error) {
+	kind = strings.ToLower(kind)
+	fn, ok := describers[kind]
+	if !ok {
+		logger.Error(errors.New("不支持describe的资源类型 " + kind))
+		return nil, errors.New("不支持describe的资源类型 " + kind)
+	}
+	result = &DescribeResult{Kind: kind, Name: name, Namespace: namespace}
+	if err := fn(result, name, namespace); err != nil {
+		return nil, err
+	}
+	result.Text = result.render()
+	return result, nil
+}
+
+// section 添加分段
+func (r *DescribeResult) section(title string) *DescribeSection {
+	section := &DescribeSection{Title: title}
+	r.Sections = append(r.Sections, section)
+	return section
+}
+
+// section 添加子分段
+func (s *DescribeSection) section(title string) *DescribeSection {
+	section := &DescribeSection{Title: title}
+	s.Sections = append(s.Sections, section)
+	return section
+}
+
+// field 添加字段，值为空时显示<none>
+func (s *DescribeSection) field(name, value string) *DescribeSection {
+	if value == "" {
+		value = "<none>"
+	}
+	s.Fields = append(s.Fields, DescribeField{Name: name, Value: value})
+	return s
+}
+
+// table 设置分段的表格
+func (s *DescribeSection) table(headers ...string) *DescribeTable {
+	s.Table = &DescribeTable{Headers: headers, Rows: [][]string{}}
+	return s.Table
+}
+
+// row 添加表格行
+func (t *DescribeTable) row(values ...string) {
+	t.Rows = append(t.Rows, values)
+}
+
+// render 将分段渲染为kubectl describe格式的文本
+func (r *DescribeResult) render() string {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	for _, section := range r.Sections {
+		section.render(w, 0)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// render 渲染分段，子分段和表格相对标题缩进两个空格
+func (s *DescribeSection) render(w *tabwriter.Writer, level int) {
+	indent := strings.Repeat("  ", level)
+	inner := level
+	if s.Title != "" {
+		fmt.Fprintf(w, "%s%s:\n", indent, s.Title)
+		inner++
+	}
+	innerIndent := strings.Repeat("  ", inner)
+	for _, field := range s.Fields {
+		lines := strings.Split(field.Value, "\n")
+		fmt.Fprintf(w, "%s%s:\t%s\n", innerIndent, field.Name, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "%s\t%s\n", innerIndent, line)
+		}
+	}
+	if s.Table != nil {
+		if len(s.Table.Rows) == 0 {
+			fmt.Fprintf(w, "%s<none>\n", innerIndent)
+		} else {
+			underlines := make([]string, len(s.Table.Headers))
+			for i, header := range s.Table.Headers {
+				underlines[i] = strings.Repeat("-", len(header))
+			}
+			fmt.Fprintf(w, "%s%s\n", innerIndent, strings.Join(s.Table.Headers, "\t"))
+			fmt.Fprintf(w, "%s%s\n", innerIndent, strings.Join(underlines, "\t"))
+			for _, row := range s.Table.Rows {
+				fmt.Fprintf(w, "%s%s\n", innerIndent, strings.Join(row, "\t"))
+			}
+		}
+	}
+	for _, section := range s.Sections {
+		section.render(w, inner)
+	}
+}
+
+// describeMeta 添加资源的基本信息
+func describeMeta(result *DescribeResult, objectMeta *metav1.ObjectMeta) *DescribeSection {
+	section := result.section("")
+	section.field("Name", objectMeta.Name)
+	if objectMeta.Namespace != "" {
+		section.field("Namespace", objectMeta.Namespace)
+	}
+	section.field("Labels", formatMap(objectMeta.Labels))
+	section.field("Annotations", formatMap(describeAnnotations(objectMeta.Annotations)))
+	section.field("CreationTimestamp", objectMeta.CreationTimestamp.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	if len(objectMeta.OwnerReferences) > 0 {
+		owner := objectMeta.OwnerReferences[0]
+		section.field("Controlled By", owner.Kind+"/"+owner.Name)
+	}
+	return section
+}
+
+// describeEvents 添加资源关联的事件，按最近发生时间排序
+func describeEvents(result *DescribeResult, namespace, name string, uid types.UID) {
+	section := result.section("Events")
+	table := section.table("Type", "Reason", "Age", "From", "Message")
+	selector := fields.Set{
+		"involvedObject.name": name,
+		"involvedObject.uid":  string(uid),
+	}.AsSelector().String()
+	eventList, err := K8s.ClientSet.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		// 事件获取失败不影响资源本身的describe
+		logger.Error(errors.New("获取" + name + "的事件失败,错误信息 " + err.Error()))
+		section.field("Error", err.Error())
+		return
+	}
+	events := eventList.Items
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+	for i := range events {
+		event := &events[i]
+		eventAge := age(eventTime(event))
+		if event.Count > 1 && !event.FirstTimestamp.IsZero() {
+			eventAge = fmt.Sprintf("%s (x%d over %s)", eventAge, event.Count, age(event.FirstTimestamp.Time))
+		}
+		from := event.Source.Component
+		if from == "" {
+			from = event.ReportingController
+		}
+		table.row(event.Type, event.Reason, eventAge, from, strings.TrimSpace(event.Message))
+	}
+}
+
+// eventTime 获取事件最后发生的时间
+func eventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
+
+// ResourceEvent 定义资源的事件摘要
+type ResourceEvent struct {
+	Type          string      `json:"type"`
+	Reason        string      `json:"reason"`
+	Message       string      `json:"message"`
+	Count         int32       `json:"count"`
+	LastTimestamp metav1.Time `json:"lastTimestamp"`
+}
+
+// recentEvents 按时间从新到旧返回最近的limit条事件摘要
+func recentEvents(events []corev1.Event, limit int) []*ResourceEvent {
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(&events[j]).Before(eventTime(&events[i]))
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	result := make([]*ResourceEvent, 0, len(events))
+	for i := range events {
+		result = append(result, &ResourceEvent{
+			Type:          events[i].Type,
+			Reason:        events[i].Reason,
+			Message:       strings.TrimSpace(events[i].Message),
+			Count:         events[i].Count,
+			LastTimestamp: metav1.NewTime(eventTime(&events[i])),
+		})
+	}
+	return result
+}
+
+// describeAnnotations 去掉内容过长的last-applied-configuration注解
+func describeAnnotations(annotations map[string]string) map[string]string {
+	result := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		if key == corev1.LastAppliedConfigAnnotation {
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// formatMap 将map格式化为按key排序的多行key=value
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + m[key]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatResourceList 将资源配额格式化为多行name: quantity
+func formatResourceList(resourceList corev1.ResourceList) string {
+	names := make([]string, 0, len(resourceList))
+	for name := range resourceList {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		quantity := resourceList[corev1.ResourceName(name)]
+		lines[i] = name + ": " + quantity.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatSelector 将选择器格式化为逗号分隔的key=value
+func formatSelector(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return err.Error()
+	}
+	return s.String()
+}
@@ -0,0 +1,640 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 各资源类型的describe实现
+
+// describePod describe pod，包含容器、状态条件、卷以及事件
+func describePod(result *DescribeResult, name, namespace string) error {
+	pod, err := Pod.GetPodDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &pod.ObjectMeta)
+	section.field("Node", pod.Spec.NodeName+"/"+pod.Status.HostIP)
+	if pod.Status.StartTime != nil {
+		section.field("Start Time", pod.Status.StartTime.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	}
+	section.field("Status", podStatus(pod))
+	section.field("Reason", pod.Status.Reason)
+	section.field("Message", pod.Status.Message)
+	section.field("IP", pod.Status.PodIP)
+	section.field("QoS Class", string(pod.Status.QOSClass))
+	section.field("Service Account", pod.Spec.ServiceAccountName)
+	section.field("Node-Selectors", formatMap(pod.Spec.NodeSelector))
+
+	if len(pod.Spec.InitContainers) > 0 {
+		describeContainers(result.section("Init Containers"), pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
+	}
+	describeContainers(result.section("Containers"), pod.Spec.Containers, pod.Status.ContainerStatuses)
+	if len(pod.Spec.EphemeralContainers) > 0 {
+		containers := make([]corev1.Container, len(pod.Spec.EphemeralContainers))
+		for i, container := range pod.Spec.EphemeralContainers {
+			containers[i] = corev1.Container(container.EphemeralContainerCommon)
+		}
+		describeContainers(result.section("Ephemeral Containers"), containers, pod.Status.EphemeralContainerStatuses)
+	}
+
+	conditions := result.section("Conditions").table("Type", "Status")
+	for _, condition := range pod.Status.Conditions {
+		conditions.row(string(condition.Type), string(condition.Status))
+	}
+	volumes := result.section("Volumes").table("Name", "Type", "Source")
+	for _, volume := range pod.Spec.Volumes {
+		volumeType, source := volumeSource(volume.VolumeSource)
+		volumes.row(volume.Name, volumeType, source)
+	}
+	tolerations := make([]string, len(pod.Spec.Tolerations))
+	for i, toleration := range pod.Spec.Tolerations {
+		tolerations[i] = formatToleration(toleration)
+	}
+	result.section("").field("Tolerations", strings.Join(tolerations, "\n"))
+	describeEvents(result, pod.Namespace, pod.Name, pod.UID)
+	return nil
+}
+
+// describeContainers 为每个容器添加子分段
+func describeContainers(section *DescribeSection, containers []corev1.Container, statuses []corev1.ContainerStatus) {
+	for _, container := range containers {
+		sub := section.section(container.Name)
+		sub.field("Image", container.Image)
+		ports := make([]string, len(container.Ports))
+		for i, port := range container.Ports {
+			ports[i] = fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol)
+		}
+		sub.field("Ports", strings.Join(ports, ", "))
+		if len(container.Command) > 0 {
+			sub.field("Command", strings.Join(container.Command, "\n"))
+		}
+		if len(container.Args) > 0 {
+			sub.field("Args", strings.Join(container.Args, "\n"))
+		}
+		for _, status := range statuses {
+			if status.Name != container.Name {
+				continue
+			}
+			sub.field("State", containerState(status.State))
+			if status.LastTerminationState.Terminated != nil {
+				sub.field("Last State", containerState(status.LastTerminationState))
+			}
+			sub.field("Ready", strconv.FormatBool(status.Ready))
+			sub.field("Restart Count", strconv.Itoa(int(status.RestartCount)))
+		}
+		if len(container.Resources.Limits) > 0 {
+			sub.field("Limits", formatResourceList(container.Resources.Limits))
+		}
+		if len(container.Resources.Requests) > 0 {
+			sub.field("Requests", formatResourceList(container.Resources.Requests))
+		}
+		env := make([]string, len(container.Env))
+		for i, envVar := range container.Env {
+			value := envVar.Value
+			if envVar.ValueFrom != nil {
+				value = "<set from reference>"
+			}
+			env[i] = envVar.Name + ": " + value
+		}
+		sub.field("Environment", strings.Join(env, "\n"))
+		mounts := make([]string, len(container.VolumeMounts))
+		for i, mount := range container.VolumeMounts {
+			mode := "rw"
+			if mount.ReadOnly {
+				mode = "ro"
+			}
+			mounts[i] = mount.MountPath + " from " + mount.Name + " (" + mode + ")"
+		}
+		sub.field("Mounts", strings.Join(mounts, "\n"))
+	}
+}
+
+// containerState 格式化容器状态
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "Running\nStarted: " + state.Running.StartedAt.Format("Mon, 02 Jan 2006 15:04:05 -0700")
+	case state.Waiting != nil:
+		return "Waiting\nReason: " + state.Waiting.Reason
+	case state.Terminated != nil:
+		return fmt.Sprintf("Terminated\nReason: %s\nExit Code: %d", state.Terminated.Reason, state.Terminated.ExitCode)
+	}
+	return "Waiting"
+}
+
+// volumeSource 获取卷的类型和来源
+func volumeSource(source corev1.VolumeSource) (string, string) {
+	switch {
+	case source.ConfigMap != nil:
+		return "ConfigMap", source.ConfigMap.Name
+	case source.Secret != nil:
+		return "Secret", source.Secret.SecretName
+	case source.PersistentVolumeClaim != nil:
+		return "PersistentVolumeClaim", source.PersistentVolumeClaim.ClaimName
+	case source.EmptyDir != nil:
+		return "EmptyDir", string(source.EmptyDir.Medium)
+	case source.HostPath != nil:
+		return "HostPath", source.HostPath.Path
+	case source.Projected != nil:
+		return "Projected", ""
+	case source.DownwardAPI != nil:
+		return "DownwardAPI", ""
+	case source.NFS != nil:
+		return "NFS", source.NFS.Server + ":" + source.NFS.Path
+	case source.CSI != nil:
+		return "CSI", source.CSI.Driver
+	}
+	return "Other", ""
+}
+
+// formatToleration 格式化容忍度，如node.kubernetes.io/not-ready:NoExecute op=Exists for 300s
+func formatToleration(toleration corev1.Toleration) string {
+	s := toleration.Key
+	if toleration.Value != "" {
+		s += "=" + toleration.Value
+	}
+	if toleration.Effect != "" {
+		s += ":" + string(toleration.Effect)
+	}
+	if toleration.Operator == corev1.TolerationOpExists && toleration.Value == "" {
+		s += " op=Exists"
+	}
+	if toleration.TolerationSeconds != nil {
+		s += fmt.Sprintf(" for %ds", *toleration.TolerationSeconds)
+	}
+	return s
+}
+
+// describePodTemplate 添加pod模板的分段
+func describePodTemplate(result *DescribeResult, template *corev1.PodTemplateSpec) {
+	section := result.section("Pod Template")
+	section.field("Labels", formatMap(template.Labels))
+	describeContainers(section.section("Containers"), template.Spec.Containers, nil)
+	volumes := section.section("Volumes").table("Name", "Type", "Source")
+	for _, volume := range template.Spec.Volumes {
+		volumeType, source := volumeSource(volume.VolumeSource)
+		volumes.row(volume.Name, volumeType, source)
+	}
+}
+
+// describeDeployment describe deployment，包含副本数、更新策略、状态条件、ReplicaSet以及事件
+func describeDeployment(result *DescribeResult, name, namespace string) error {
+	deployment, err := Deployment.GetDeploymentDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &deployment.ObjectMeta)
+	section.field("Selector", formatSelector(deployment.Spec.Selector))
+	summary := deploymentSummary(deployment)
+	section.field("Replicas", fmt.Sprintf("%d desired | %d updated | %d total | %d available | %d unavailable",
+		summary.DesiredReplicas, deployment.Status.UpdatedReplicas, deployment.Status.Replicas,
+		deployment.Status.AvailableReplicas, deployment.Status.UnavailableReplicas))
+	section.field("StrategyType", string(deployment.Spec.Strategy.Type))
+	section.field("MinReadySeconds", strconv.Itoa(int(deployment.Spec.MinReadySeconds)))
+	if rollingUpdate := deployment.Spec.Strategy.RollingUpdate; rollingUpdate != nil {
+		section.field("RollingUpdateStrategy", fmt.Sprintf("%s max unavailable, %s max surge",
+			rollingUpdate.MaxUnavailable.String(), rollingUpdate.MaxSurge.String()))
+	}
+	// hpa获取失败不影响deployment本身的describe
+	if hpa, err := HPA.findTarget("Deployment", deployment.Name, deployment.Namespace); err == nil && hpa != nil {
+		section.field("HorizontalPodAutoscaler", hpa.Name)
+	}
+	describePodTemplate(result, &deployment.Spec.Template)
+
+	conditions := result.section("Conditions").table("Type", "Status", "Reason")
+	for _, condition := range deployment.Status.Conditions {
+		conditions.row(string(condition.Type), string(condition.Status), condition.Reason)
+	}
+	replicaSets := result.section("ReplicaSets").table("Name", "Revision", "Desired", "Current", "Ready", "Age")
+	replicaSetList, err := K8s.ClientSet.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: formatSelector(deployment.Spec.Selector),
+	})
+	if err != nil {
+		logger.Error(errors.New("获取deployment " + name + "的ReplicaSet失败,错误信息 " + err.Error()))
+		return errors.New("获取deployment " + name + "的ReplicaSet失败,错误信息 " + err.Error())
+	}
+	owned := ownedReplicaSets(replicaSetList.Items, deployment.UID)
+	for _, replicaSet := range owned {
+		var desired int32
+		if replicaSet.Spec.Replicas != nil {
+			desired = *replicaSet.Spec.Replicas
+		}
+		replicaSets.row(replicaSet.Name, replicaSet.Annotations[revisionAnnotation],
+			strconv.Itoa(int(desired)), strconv.Itoa(int(replicaSet.Status.Replicas)),
+			strconv.Itoa(int(replicaSet.Status.ReadyReplicas)), age(replicaSet.CreationTimestamp.Time))
+	}
+	describeEvents(result, deployment.Namespace, deployment.Name, deployment.UID)
+	return nil
+}
+
+// ownedReplicaSets 获取属于指定deployment的ReplicaSet，按revision从新到旧排序
+func ownedReplicaSets(replicaSets []appsv1.ReplicaSet, uid types.UID) []*appsv1.ReplicaSet {
+	var owned []*appsv1.ReplicaSet
+	for i := range replicaSets {
+		if controllerRef := metav1.GetControllerOf(&replicaSets[i]); controllerRef != nil && controllerRef.UID == uid {
+			owned = append(owned, &replicaSets[i])
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		return revisionOf(owned[i].ObjectMeta) > revisionOf(owned[j].ObjectMeta)
+	})
+	return owned
+}
+
+// revisionOf 获取ReplicaSet的revision注解
+func revisionOf(objectMeta metav1.ObjectMeta) int64 {
+	revision, _ := strconv.ParseInt(objectMeta.Annotations[revisionAnnotation], 10, 64)
+	return revision
+}
+
+// describeStatefulSet describe statefulset，包含副本数、pod状态、卷声明模板以及事件
+func describeStatefulSet(result *DescribeResult, name, namespace string) error {
+	statefulSet, err := StatefulSet.GetStatefulSetDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &statefulSet.ObjectMeta)
+	section.field("Selector", formatSelector(statefulSet.Spec.Selector))
+	var desired int32 = 1
+	if statefulSet.Spec.Replicas != nil {
+		desired = *statefulSet.Spec.Replicas
+	}
+	section.field("Replicas", fmt.Sprintf("%d desired | %d total", desired, statefulSet.Status.Replicas))
+	section.field("Update Strategy", string(statefulSet.Spec.UpdateStrategy.Type))
+	section.field("Service Name", statefulSet.Spec.ServiceName)
+	section.field("Pod Management Policy", string(statefulSet.Spec.PodManagementPolicy))
+	if err := describePodsStatus(section, namespace, statefulSet.Spec.Selector, statefulSet.UID); err != nil {
+		return err
+	}
+	describePodTemplate(result, &statefulSet.Spec.Template)
+
+	claims := result.section("Volume Claims").table("Name", "StorageClass", "Capacity", "Access Modes")
+	for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		storageClass := ""
+		if claim.Spec.StorageClassName != nil {
+			storageClass = *claim.Spec.StorageClassName
+		}
+		capacity := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		claims.row(claim.Name, storageClass, capacity.String(), formatAccessModes(claim.Spec.AccessModes))
+	}
+	describeEvents(result, statefulSet.Namespace, statefulSet.Name, statefulSet.UID)
+	return nil
+}
+
+// describeDaemonSet describe daemonset，包含调度数量、pod状态以及事件
+func describeDaemonSet(result *DescribeResult, name, namespace string) error {
+	daemonSet, err := DaemonSet.GetDaemonSetDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &daemonSet.ObjectMeta)
+	section.field("Selector", formatSelector(daemonSet.Spec.Selector))
+	section.field("Node-Selector", formatMap(daemonSet.Spec.Template.Spec.NodeSelector))
+	section.field("Desired Number of Nodes Scheduled", strconv.Itoa(int(daemonSet.Status.DesiredNumberScheduled)))
+	section.field("Current Number of Nodes Scheduled", strconv.Itoa(int(daemonSet.Status.CurrentNumberScheduled)))
+	section.field("Number of Nodes Scheduled with Up-to-date Pods", strconv.Itoa(int(daemonSet.Status.UpdatedNumberScheduled)))
+	section.field("Number of Nodes Scheduled with Available Pods", strconv.Itoa(int(daemonSet.Status.NumberAvailable)))
+	section.field("Number of Nodes Misscheduled", strconv.Itoa(int(daemonSet.Status.NumberMisscheduled)))
+	if err := describePodsStatus(section, namespace, daemonSet.Spec.Selector, daemonSet.UID); err != nil {
+		return err
+	}
+	describePodTemplate(result, &daemonSet.Spec.Template)
+	describeEvents(result, daemonSet.Namespace, daemonSet.Name, daemonSet.UID)
+	return nil
+}
+
+// describePodsStatus 统计控制器所属pod的各阶段数量
+func describePodsStatus(section *DescribeSection, namespace string, selector *metav1.LabelSelector, uid types.UID) error {
+	podList, err := K8s.ClientSet.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: formatSelector(selector),
+	})
+	if err != nil {
+		logger.Error(errors.New("获取pod列表失败,错误信息 " + err.Error()))
+		return errors.New("获取pod列表失败,错误信息 " + err.Error())
+	}
+	phases := map[corev1.PodPhase]int{}
+	for i := range podList.Items {
+		if controllerRef := metav1.GetControllerOf(&podList.Items[i]); controllerRef != nil && controllerRef.UID == uid {
+			phases[podList.Items[i].Status.Phase]++
+		}
+	}
+	section.field("Pods Status", fmt.Sprintf("%d Running / %d Waiting / %d Succeeded / %d Failed",
+		phases[corev1.PodRunning], phases[corev1.PodPending], phases[corev1.PodSucceeded], phases[corev1.PodFailed]))
+	return nil
+}
+
+// describeService describe service，包含端口、选择器、endpoints以及事件
+func describeService(result *DescribeResult, name, namespace string) error {
+	service, err := Servicev1.GetServiceDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &service.ObjectMeta)
+	section.field("Selector", formatMap(service.Spec.Selector))
+	section.field("Type", string(service.Spec.Type))
+	section.field("IP Families", formatIPFamilies(service.Spec.IPFamilies))
+	section.field("IPs", strings.Join(service.Spec.ClusterIPs, ","))
+	if len(service.Spec.ExternalIPs) > 0 {
+		section.field("External IPs", strings.Join(service.Spec.ExternalIPs, ","))
+	}
+	ingresses := make([]string, len(service.Status.LoadBalancer.Ingress))
+	for i, ingress := range service.Status.LoadBalancer.Ingress {
+		ingresses[i] = ingress.IP + ingress.Hostname
+	}
+	if len(ingresses) > 0 {
+		section.field("LoadBalancer Ingress", strings.Join(ingresses, ", "))
+	}
+	section.field("Session Affinity", string(service.Spec.SessionAffinity))
+
+	endpoints, err := K8s.ClientSet.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		endpoints = &corev1.Endpoints{}
+	}
+	ports := result.section("Ports").table("Name", "Port", "TargetPort", "NodePort", "Endpoints")
+	for _, port := range service.Spec.Ports {
+		nodePort := ""
+		if port.NodePort != 0 {
+			nodePort = fmt.Sprintf("%d/%s", port.NodePort, port.Protocol)
+		}
+		ports.row(port.Name, fmt.Sprintf("%d/%s", port.Port, port.Protocol), port.TargetPort.String(),
+			nodePort, formatEndpoints(endpoints, port.Name))
+	}
+	describeEvents(result, service.Namespace, service.Name, service.UID)
+	return nil
+}
+
+// formatIPFamilies 格式化service的ip协议族
+func formatIPFamilies(families []corev1.IPFamily) string {
+	values := make([]string, len(families))
+	for i, family := range families {
+		values[i] = string(family)
+	}
+	return strings.Join(values, ",")
+}
+
+// formatEndpoints 获取service端口对应的endpoints地址
+func formatEndpoints(endpoints *corev1.Endpoints, portName string) string {
+	var addresses []string
+	for _, subset := range endpoints.Subsets {
+		for _, port := range subset.Ports {
+			if port.Name != portName {
+				continue
+			}
+			for _, address := range subset.Addresses {
+				addresses = append(addresses, fmt.Sprintf("%s:%d", address.IP, port.Port))
+			}
+		}
+	}
+	return strings.Join(addresses, ",")
+}
+
+// describeIngress describe ingress，包含规则、TLS、默认后端以及事件
+func describeIngress(result *DescribeResult, name, namespace string) error {
+	ingress, err := Ingress.GetIngressDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &ingress.ObjectMeta)
+	if ingress.Spec.IngressClassName != nil {
+		section.field("Ingress Class", *ingress.Spec.IngressClassName)
+	}
+	addresses := make([]string, len(ingress.Status.LoadBalancer.Ingress))
+	for i, lb := range ingress.Status.LoadBalancer.Ingress {
+		addresses[i] = lb.IP + lb.Hostname
+	}
+	section.field("Address", strings.Join(addresses, ","))
+	if backend := ingress.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		section.field("Default backend", backend.Service.Name+":"+formatBackendPort(backend.Service.Port.Name, backend.Service.Port.Number))
+	}
+	tls := make([]string, len(ingress.Spec.TLS))
+	for i, t := range ingress.Spec.TLS {
+		tls[i] = t.SecretName + " terminates " + strings.Join(t.Hosts, ",")
+	}
+	section.field("TLS", strings.Join(tls, "\n"))
+
+	rules := result.section("Rules").table("Host", "Path", "Backends")
+	for _, rule := range ingress.Spec.Rules {
+		host := rule.Host
+		if host == "" {
+			host = "*"
+		}
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backend := ""
+			if path.Backend.Service != nil {
+				backend = path.Backend.Service.Name + ":" + formatBackendPort(path.Backend.Service.Port.Name, path.Backend.Service.Port.Number)
+			}
+			rules.row(host, path.Path, backend)
+		}
+	}
+	describeEvents(result, ingress.Namespace, ingress.Name, ingress.UID)
+	return nil
+}
+
+// formatBackendPort 格式化ingress后端端口
+func formatBackendPort(name string, number int32) string {
+	if name != "" {
+		return name
+	}
+	return strconv.Itoa(int(number))
+}
+
+// describeNode describe node，包含状态条件、地址、容量、系统信息、污点、pod资源请求以及事件
+func describeNode(result *DescribeResult, name, namespace string) error {
+	node, err := Node.GetNodeDetail(name)
+	if err != nil {
+		return err
+	}
+	result.Namespace = ""
+	section := describeMeta(result, &node.ObjectMeta)
+	var roles []string
+	for label := range node.Labels {
+		if strings.HasPrefix(label, "node-role.kubernetes.io/") {
+			roles = append(roles, strings.TrimPrefix(label, "node-role.kubernetes.io/"))
+		}
+	}
+	sort.Strings(roles)
+	section.field("Roles", strings.Join(roles, ","))
+	taints := make([]string, len(node.Spec.Taints))
+	for i, taint := range node.Spec.Taints {
+		taints[i] = taint.ToString()
+	}
+	section.field("Taints", strings.Join(taints, "\n"))
+	section.field("Unschedulable", strconv.FormatBool(node.Spec.Unschedulable))
+
+	conditions := result.section("Conditions").table("Type", "Status", "LastHeartbeatTime", "Reason", "Message")
+	for _, condition := range node.Status.Conditions {
+		conditions.row(string(condition.Type), string(condition.Status),
+			condition.LastHeartbeatTime.Format("Mon, 02 Jan 2006 15:04:05 -0700"), condition.Reason, condition.Message)
+	}
+	addresses := result.section("Addresses")
+	for _, address := range node.Status.Addresses {
+		addresses.field(string(address.Type), address.Address)
+	}
+	result.section("Capacity").Fields = resourceFields(node.Status.Capacity)
+	result.section("Allocatable").Fields = resourceFields(node.Status.Allocatable)
+	info := node.Status.NodeInfo
+	result.section("System Info").
+		field("Machine ID", info.MachineID).
+		field("Kernel Version", info.KernelVersion).
+		field("OS Image", info.OSImage).
+		field("Operating System", info.OperatingSystem).
+		field("Architecture", info.Architecture).
+		field("Container Runtime Version", info.ContainerRuntimeVersion).
+		field("Kubelet Version", info.KubeletVersion)
+
+	podList, err := K8s.ClientSet.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + name + ",status.phase!=" + string(corev1.PodSucceeded) + ",status.phase!=" + string(corev1.PodFailed),
+	})
+	if err != nil {
+		logger.Error(errors.New("获取node " + name + "上的pod失败,错误信息 " + err.Error()))
+		return errors.New("获取node " + name + "上的pod失败,错误信息 " + err.Error())
+	}
+	pods := result.section(fmt.Sprintf("Non-terminated Pods (%d in total)", len(podList.Items))).
+		table("Namespace", "Name", "CPU Requests", "Memory Requests", "Age")
+	for _, pod := range podList.Items {
+		requests := podRequests(pod.Spec)
+		cpu := requests[corev1.ResourceCPU]
+		memory := requests[corev1.ResourceMemory]
+		pods.row(pod.Namespace, pod.Name, cpu.String(), memory.String(), age(pod.CreationTimestamp.Time))
+	}
+	// node事件的involvedObject.uid为node名称
+	describeEvents(result, "", node.Name, types.UID(node.Name))
+	return nil
+}
+
+// resourceFields 将资源容量转换为字段
+func resourceFields(resourceList corev1.ResourceList) []DescribeField {
+	var fields []DescribeField
+	for _, line := range strings.Split(formatResourceList(resourceList), "\n") {
+		if name, value, ok := strings.Cut(line, ": "); ok {
+			fields = append(fields, DescribeField{Name: name, Value: value})
+		}
+	}
+	return fields
+}
+
+// podRequests 计算pod中所有容器的资源请求之和
+func podRequests(spec corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	return requests
+}
+
+// describePv describe pv，包含状态、绑定的pvc、回收策略、容量、来源以及事件
+func describePv(result *DescribeResult, name, namespace string) error {
+	pv, err := Pv.GetPvDetail(name)
+	if err != nil {
+		return err
+	}
+	result.Namespace = ""
+	section := describeMeta(result, &pv.ObjectMeta)
+	section.field("StorageClass", pv.Spec.StorageClassName)
+	section.field("Status", string(pv.Status.Phase))
+	claim := ""
+	if pv.Spec.ClaimRef != nil {
+		claim = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+	}
+	section.field("Claim", claim)
+	section.field("Reclaim Policy", string(pv.Spec.PersistentVolumeReclaimPolicy))
+	section.field("Access Modes", formatAccessModes(pv.Spec.AccessModes))
+	if pv.Spec.VolumeMode != nil {
+		section.field("VolumeMode", string(*pv.Spec.VolumeMode))
+	}
+	capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+	section.field("Capacity", capacity.String())
+	section.field("Message", pv.Status.Message)
+	source := result.section("Source")
+	switch {
+	case pv.Spec.HostPath != nil:
+		source.field("Type", "HostPath").field("Path", pv.Spec.HostPath.Path)
+	case pv.Spec.NFS != nil:
+		source.field("Type", "NFS").field("Server", pv.Spec.NFS.Server).field("Path", pv.Spec.NFS.Path)
+	case pv.Spec.Local != nil:
+		source.field("Type", "LocalVolume").field("Path", pv.Spec.Local.Path)
+	case pv.Spec.CSI != nil:
+		source.field("Type", "CSI").field("Driver", pv.Spec.CSI.Driver).field("VolumeHandle", pv.Spec.CSI.VolumeHandle)
+	default:
+		source.field("Type", "Other")
+	}
+	describeEvents(result, "", pv.Name, pv.UID)
+	return nil
+}
+
+// describePvc describe pvc，包含状态、绑定的pv、容量、使用该pvc的pod以及事件
+func describePvc(result *DescribeResult, name, namespace string) error {
+	pvc, err := Pvc.GetPvcDetail(name, namespace)
+	if err != nil {
+		return err
+	}
+	section := describeMeta(result, &pvc.ObjectMeta)
+	storageClass := ""
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+	section.field("StorageClass", storageClass)
+	section.field("Status", string(pvc.Status.Phase))
+	section.field("Volume", pvc.Spec.VolumeName)
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	section.field("Capacity", capacity.String())
+	section.field("Access Modes", formatAccessModes(pvc.Status.AccessModes))
+	if pvc.Spec.VolumeMode != nil {
+		section.field("VolumeMode", string(*pvc.Spec.VolumeMode))
+	}
+
+	podList, err := K8s.ClientSet.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取namespace " + namespace + "下的pod失败,错误信息 " + err.Error()))
+		return errors.New("获取namespace " + namespace + "下的pod失败,错误信息 " + err.Error())
+	}
+	var usedBy []string
+	for _, pod := range podList.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == name {
+				usedBy = append(usedBy, pod.Name)
+				break
+			}
+		}
+	}
+	section.field("Used By", strings.Join(usedBy, "\n"))
+	describeEvents(result, pvc.Namespace, pvc.Name, pvc.UID)
+	return nil
+}
+
+// formatAccessModes 格式化访问模式，与kubectl一致使用缩写，如RWO、ROX
+func formatAccessModes(modes []corev1.PersistentVolumeAccessMode) string {
+	values := make([]string, len(modes))
+	for i, mode := range modes {
+		switch mode {
+		case corev1.ReadWriteOnce:
+			values[i] = "RWO"
+		case corev1.ReadOnlyMany:
+			values[i] = "ROX"
+		case corev1.ReadWriteMany:
+			values[i] = "RWX"
+		case corev1.ReadWriteOncePod:
+			values[i] = "RWOP"
+		default:
+			values[i] = string(mode)
+		}
+	}
+	return strings.Join(values, ",")
+}
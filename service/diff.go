@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/wonderivan/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// 更新前的diff预览，将提交的对象以dryRun方式提交到apiserver，经过默认值填充和准入校验后
+// 与当前对象对比，返回unified diff，与kubectl diff类似
+
+// Diff 定义Diff全局变量
+var Diff diff
+
+// 定义diff结构体
+type diff struct{}
+
+// diffIgnoredMetadataFields 对比时忽略的metadata字段，这些字段每次更新都会变化，不属于用户修改的内容
+var diffIgnoredMetadataFields = []string{"managedFields", "resourceVersion"}
+
+// DiffResp 定义diff预览的返回内容，Action为create或update，对象不存在时与空内容对比
+type DiffResp struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Action    string `json:"action"`
+	Changed   bool   `json:"changed"`
+	Diff      string `json:"diff"`
+}
+
+// Preview 预览更新的内容，content为单个对象的yaml或json
+// 对象中没有apiVersion和kind时(如clientset返回的详情)，通过resourceType确定资源类型
+// 对象中的resourceVersion与当前对象不一致时返回ConflictError
+func (d *diff) Preview(resourceType *ResourceType, namespace, content string) (diffResp *DiffResp, err error) {
+	data, err := yaml.YAMLToJSON([]byte(content))
+	if err != nil {
+		logger.Error(errors.New("解析yaml失败,错误信息 " + err.Error()))
+		return nil, errors.New("解析yaml失败,错误信息 " + err.Error())
+	}
+	obj, err := Resource.decode(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetName() == "" {
+		logger.Error(errors.New("对象缺少metadata.name"))
+		return nil, errors.New("对象缺少metadata.name")
+	}
+	if obj.GetKind() == "" {
+		gvr, _, err := Resource.resolve(resourceType)
+		if err != nil {
+			return nil, err
+		}
+		gvk, err := K8s.RESTMapper.KindFor(gvr)
+		if err != nil {
+			logger.Error(errors.New("获取资源类型 " + gvr.String() + "的Kind失败,错误信息 " + err.Error()))
+			return nil, errors.New("获取资源类型 " + gvr.String() + "的Kind失败,错误信息 " + err.Error())
+		}
+		obj.SetGroupVersionKind(gvk)
+	}
+	client, gvr, err := objectClient(obj, namespace)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	diffResp = &DiffResp{Kind: obj.GetKind(), Name: obj.GetName(), Namespace: obj.GetNamespace()}
+
+	// managedFields由apiserver维护，从详情中复制的内容不需要提交
+	obj.SetManagedFields(nil)
+	dryRun := []string{dryRunAll}
+	var live, merged *unstructured.Unstructured
+	live, err = client.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		live = nil
+		diffResp.Action = "create"
+		merged, err = client.Create(context.TODO(), obj, metav1.CreateOptions{DryRun: dryRun})
+	case err != nil:
+		logger.Error(errors.New("获取" + obj.GetKind() + " " + obj.GetName() + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取" + obj.GetKind() + " " + obj.GetName() + "失败,错误信息 " + err.Error())
+	default:
+		diffResp.Action = "update"
+		merged, err = client.Update(context.TODO(), obj, metav1.UpdateOptions{DryRun: dryRun})
+	}
+	if err != nil {
+		if conflict := conflictError(err, obj.GetKind(), gvr, obj.GetNamespace(), obj); conflict != nil {
+			return nil, conflict
+		}
+		logger.Error(errors.New("dryRun " + obj.GetKind() + " " + obj.GetName() + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("dryRun " + obj.GetKind() + " " + obj.GetName() + "失败,错误信息 " + err.Error())
+	}
+
+	from, err := diffYaml(live)
+	if err != nil {
+		return nil, err
+	}
+	to, err := diffYaml(merged)
+	if err != nil {
+		return nil, err
+	}
+	diffResp.Diff, err = unifiedDiff(from, to, "live/"+obj.GetName(), "merged/"+obj.GetName())
+	if err != nil {
+		return nil, err
+	}
+	diffResp.Changed = diffResp.Diff != ""
+	return diffResp, nil
+}
+
+// diffYaml 去掉对比时忽略的字段后序列化为yaml，对象为nil时返回空内容
+func diffYaml(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	object := obj.DeepCopy().Object
+	for _, field := range diffIgnoredMetadataFields {
+		unstructured.RemoveNestedField(object, "metadata", field)
+	}
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		logger.Error(errors.New("序列化yaml失败,错误信息 " + err.Error()))
+		return "", errors.New("序列化yaml失败,错误信息 " + err.Error())
+	}
+	return string(data), nil
+}
+
+// unifiedDiff 生成两段文本的unified diff，内容相同时返回空字符串
+func unifiedDiff(from, to, fromFile, toFile string) (string, error) {
+	result, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		logger.Error(errors.New("生成diff失败,错误信息 " + err.Error()))
+		return "", errors.New("生成diff失败,错误信息 " + err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"github.com/wonderivan/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// 详情接口的输出格式，支持yaml输出以及导出模式
+// 导出模式去掉managedFields、status以及集群分配的字段，输出的内容可以直接在其他集群或namespace中apply
+
+// 详情接口支持的输出格式
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// DetailFormat 定义详情接口的输出格式参数，嵌入到各详情接口的参数结构体中
+// Format为yaml时返回yaml文本，Export为true时返回导出模式的内容
+type DetailFormat struct {
+	Format string `form:"format"`
+	Export bool   `form:"export"`
+}
+
+// exportMetadataFields 导出时去掉的metadata字段
+var exportMetadataFields = []string{
+	"managedFields", "resourceVersion", "uid", "creationTimestamp", "generation",
+	"selfLink", "deletionTimestamp", "deletionGracePeriodSeconds", "ownerReferences",
+}
+
+// exportAnnotations 导出时去掉的注解，均由kubectl或控制器写入
+var exportAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"pv.kubernetes.io/provisioned-by",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+}
+
+// exportSpecFields 导出时按资源类型去掉的由集群分配的spec字段
+var exportSpecFields = map[string][]string{
+	"Pod":                   {"nodeName"},
+	"Service":               {"clusterIP", "clusterIPs", "healthCheckNodePort"},
+	"PersistentVolume":      {"claimRef"},
+	"PersistentVolumeClaim": {"volumeName"},
+}
+
+// FormatDetail 按输出格式参数转换详情接口返回的资源对象
+// 默认原样返回，yaml格式返回yaml文本，导出模式返回去掉集群字段后的内容
+func FormatDetail(obj runtime.Object, params *DetailFormat) (content interface{}, err error) {
+	switch params.Format {
+	case "", FormatJSON, FormatYAML:
+	default:
+		logger.Error(errors.New("不支持的format参数 " + params.Format + ",可选值为json、yaml"))
+		return nil, errors.New("不支持的format参数 " + params.Format + ",可选值为json、yaml")
+	}
+	if params.Format != FormatYAML && !params.Export {
+		return obj, nil
+	}
+	object, err := toUnstructuredMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	if params.Export {
+		exportCleanup(object)
+	}
+	if params.Format != FormatYAML {
+		return object, nil
+	}
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		logger.Error(errors.New("序列化yaml失败,错误信息 " + err.Error()))
+		return nil, errors.New("序列化yaml失败,错误信息 " + err.Error())
+	}
+	return string(data), nil
+}
+
+// toUnstructuredMap 将资源对象转换为map，clientset返回的对象不包含apiVersion和kind，需要从scheme中补充
+func toUnstructuredMap(obj runtime.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy().Object, nil
+	}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		logger.Error(errors.New("转换资源对象失败,错误信息 " + err.Error()))
+		return nil, errors.New("转换资源对象失败,错误信息 " + err.Error())
+	}
+	if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+		apiVersion, kind := gvks[0].ToAPIVersionAndKind()
+		object["apiVersion"] = apiVersion
+		object["kind"] = kind
+	}
+	return object, nil
+}
+
+// exportCleanup 去掉status以及集群生成或分配的字段
+func exportCleanup(object map[string]interface{}) {
+	delete(object, "status")
+	for _, field := range exportMetadataFields {
+		unstructured.RemoveNestedField(object, "metadata", field)
+	}
+	for _, annotation := range exportAnnotations {
+		unstructured.RemoveNestedField(object, "metadata", "annotations", annotation)
+	}
+	if annotations, found, _ := unstructured.NestedMap(object, "metadata", "annotations"); found && len(annotations) == 0 {
+		unstructured.RemoveNestedField(object, "metadata", "annotations")
+	}
+	kind, _, _ := unstructured.NestedString(object, "kind")
+	for _, field := range exportSpecFields[kind] {
+		// headless service的clusterIP为None，属于用户配置，需要保留
+		if kind == "Service" && field != "healthCheckNodePort" {
+			if clusterIP, _, _ := unstructured.NestedString(object, "spec", "clusterIP"); clusterIP == "None" {
+				continue
+			}
+		}
+		unstructured.RemoveNestedField(object, "spec", field)
+	}
+}
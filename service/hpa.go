@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sort"
+)
+
+// HPA 实例化hpa结构体
+var HPA hpa
+
+// 定义hpa结构体
+type hpa struct{}
+
+// hpaRecentEvents 状态中返回的最近扩缩容事件数量
+const hpaRecentEvents = 10
+
+// HPAsResp 定义hpa列表的返回内容
+type HPAsResp struct {
+	Items []autoscalingv2.HorizontalPodAutoscaler `json:"items"`
+	Total int                                     `json:"total"`
+	ListMeta
+}
+
+// HPACreate 定义HPACreate结构体，用于创建hpa需要的参数属性的定义
+// TargetKind可选值为Deployment、StatefulSet，默认为Deployment
+// CpuUtilization和MemoryUtilization为资源使用率的目标百分比，都为空时使用k8s默认的cpu 80%
+type HPACreate struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Label             map[string]string `json:"label"`
+	TargetKind        string            `json:"targetKind"`
+	TargetName        string            `json:"targetName"`
+	MinReplicas       *int32            `json:"minReplicas"`
+	MaxReplicas       int32             `json:"maxReplicas"`
+	CpuUtilization    *int32            `json:"cpuUtilization"`
+	MemoryUtilization *int32            `json:"memoryUtilization"`
+}
+
+// HPAMetric 定义hpa的一项指标，Current为当前值，Target为目标值，指标尚未采集到时Current为<unknown>
+type HPAMetric struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Target  string `json:"target"`
+}
+
+// HPAStatus 定义hpa的运行状态，包括各指标的当前值与目标值、副本数以及最近的扩缩容事件
+type HPAStatus struct {
+	Name            string                                           `json:"name"`
+	Namespace       string                                           `json:"namespace"`
+	Target          string                                           `json:"target"`
+	MinReplicas     int32                                            `json:"minReplicas"`
+	MaxReplicas     int32                                            `json:"maxReplicas"`
+	CurrentReplicas int32                                            `json:"currentReplicas"`
+	DesiredReplicas int32                                            `json:"desiredReplicas"`
+	LastScaleTime   *metav1.Time                                     `json:"lastScaleTime"`
+	Metrics         []*HPAMetric                                     `json:"metrics"`
+	Conditions      []autoscalingv2.HorizontalPodAutoscalerCondition `json:"conditions"`
+	Events          []*ResourceEvent                                 `json:"events"`
+}
+
+// GetHPAs 获取hpa列表，支持过滤、排序、分页，可以通过target字段按扩缩容对象过滤，如target=Deployment/nginx
+func (h *hpa) GetHPAs(filterName, namespace string, limit, page int, selectParams *SelectParams) (hpasResp *HPAsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	hpaList, err := K8s.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
+	if err != nil {
+		logger.Error(errors.New("获取hpa列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取hpa列表失败,错误信息 " + err.Error())
+	}
+	selectableData := &dataSelector{
+		GenericDataList: h.toCells(hpaList.Items),
+		DataSelectQuery: dataSelectQuery,
+	}
+	filtered := selectableData.Filter()
+	total := filtered.Total(hpaList.RemainingItemCount)
+	data := filtered.Sort().Paginate()
+
+	return &HPAsResp{
+		Items:    h.fromCells(data.GenericDataList),
+		Total:    total,
+		ListMeta: newListMeta(hpaList.ListMeta),
+	}, nil
+}
+
+// GetHPADetail 获取hpa详情
+func (h *hpa) GetHPADetail(hpaName, namespace string) (hpa *autoscalingv2.HorizontalPodAutoscaler, err error) {
+	hpa, err = K8s.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(context.TODO(), hpaName, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取hpa " + hpaName + "详情失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取hpa " + hpaName + "详情失败,错误信息 " + err.Error())
+	}
+	return hpa, nil
+}
+
+// GetHPAStatus 获取hpa的运行状态
+func (h *hpa) GetHPAStatus(hpaName, namespace string) (status *HPAStatus, err error) {
+	hpa, err := h.GetHPADetail(hpaName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return h.status(hpa)
+}
+
+// CreateHPA 创建hpa，接受HPACreate对象
+func (h *hpa) CreateHPA(data *HPACreate) (err error) {
+	targetKind := data.TargetKind
+	switch targetKind {
+	case "":
+		targetKind = "Deployment"
+	case "Deployment", "StatefulSet":
+	default:
+		logger.Error(errors.New("不支持的扩缩容对象类型 " + data.TargetKind + ",可选值为Deployment、StatefulSet"))
+		return errors.New("不支持的扩缩容对象类型 " + data.TargetKind + ",可选值为Deployment、StatefulSet")
+	}
+	if data.MaxReplicas <= 0 {
+		logger.Error(errors.New("maxReplicas必须大于0"))
+		return errors.New("maxReplicas必须大于0")
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      data.Name,
+			Namespace: data.Namespace,
+			Labels:    data.Label,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       targetKind,
+				Name:       data.TargetName,
+			},
+			MinReplicas: data.MinReplicas,
+			MaxReplicas: data.MaxReplicas,
+		},
+	}
+	for name, utilization := range map[corev1.ResourceName]*int32{corev1.ResourceCPU: data.CpuUtilization, corev1.ResourceMemory: data.MemoryUtilization} {
+		if utilization == nil {
+			continue
+		}
+		hpa.Spec.Metrics = append(hpa.Spec.Metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: name,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: utilization,
+				},
+			},
+		})
+	}
+	// map的遍历顺序不固定，按资源名排序使cpu始终在前
+	sort.Slice(hpa.Spec.Metrics, func(i, j int) bool {
+		return hpa.Spec.Metrics[i].Resource.Name < hpa.Spec.Metrics[j].Resource.Name
+	})
+	_, err = K8s.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(data.Namespace).Create(context.TODO(), hpa, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("创建hpa " + data.Name + "失败,错误信息 " + err.Error()))
+		return errors.New("创建hpa " + data.Name + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// UpdateHPA 更新hpa，content为hpa对象的json
+func (h *hpa) UpdateHPA(namespace, content string) (err error) {
+	var hpa = &autoscalingv2.HorizontalPodAutoscaler{}
+	err = json.Unmarshal([]byte(content), hpa)
+	if err != nil {
+		logger.Error(errors.New("反序列化失败,错误信息 " + err.Error()))
+		return errors.New("反序列化失败,错误信息 " + err.Error())
+	}
+	_, err = K8s.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace).Update(context.TODO(), hpa, metav1.UpdateOptions{})
+	if err != nil {
+		if conflict := conflictError(err, "HorizontalPodAutoscaler", autoscalingv2.SchemeGroupVersion.WithResource("horizontalpodautoscalers"), namespace, hpa); conflict != nil {
+			return conflict
+		}
+		logger.Error(errors.New("更新hpa " + hpa.Name + "失败,错误信息 " + err.Error()))
+		return errors.New("更新hpa " + hpa.Name + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// DeleteHPA 删除hpa，扩缩容对象保持当前副本数
+func (h *hpa) DeleteHPA(hpaName, namespace string) (err error) {
+	err = K8s.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(context.TODO(), hpaName, metav1.DeleteOptions{})
+	if err != nil {
+		logger.Error(errors.New("删除hpa " + hpaName + "失败,错误信息 " + err.Error()))
+		return errors.New("删除hpa " + hpaName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// GetTargetHPA 获取管理指定对象副本数的hpa状态，没有hpa时返回nil
+func (h *hpa) GetTargetHPA(kind, name, namespace string) (status *HPAStatus, err error) {
+	hpa, err := h.findTarget(kind, name, namespace)
+	if err != nil || hpa == nil {
+		return nil, err
+	}
+	return h.status(hpa)
+}
+
+// ScaleWarning 手动设置副本数前检查是否有hpa管理该对象，有则返回提示内容
+// hpa生效时会按指标重新计算副本数，并将副本数限制在minReplicas和maxReplicas之间，手动设置的副本数会被覆盖
+func (h *hpa) ScaleWarning(kind, name, namespace string, replicas int32) string {
+	hpa, err := h.findTarget(kind, name, namespace)
+	if err != nil || hpa == nil {
+		return ""
+	}
+	var minReplicas int32 = 1
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+	if replicas == 0 {
+		// 副本数为0时hpa停止扩缩容，直到副本数恢复为非0
+		return fmt.Sprintf("%s %s由hpa %s管理,副本数设置为0后hpa将暂停扩缩容", kind, name, hpa.Name)
+	}
+	if replicas < minReplicas || replicas > hpa.Spec.MaxReplicas {
+		return fmt.Sprintf("%s %s由hpa %s管理,副本数%d超出hpa的范围%d-%d,将被hpa调整回范围内",
+			kind, name, hpa.Name, replicas, minReplicas, hpa.Spec.MaxReplicas)
+	}
+	return fmt.Sprintf("%s %s由hpa %s管理,手动设置的副本数会被hpa按指标重新计算后覆盖", kind, name, hpa.Name)
+}
+
+// findTarget 查找管理指定apps组对象(Deployment、StatefulSet)的hpa，没有时返回nil
+// 除kind和name外还需比较scaleTargetRef的group，避免将其他组中kind和name相同的CRD误认为目标
+func (h *hpa) findTarget(kind, name, namespace string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpaList, err := K8s.ClientSet.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取hpa列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取hpa列表失败,错误信息 " + err.Error())
+	}
+	for i := range hpaList.Items {
+		ref := hpaList.Items[i].Spec.ScaleTargetRef
+		if ref.Kind != kind || ref.Name != name {
+			continue
+		}
+		if gv, err := schema.ParseGroupVersion(ref.APIVersion); err == nil && gv.Group == appsv1.GroupName {
+			return &hpaList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// status 组装hpa的运行状态，指标按spec中的顺序与status中的当前值一一对应，与kubectl describe hpa一致
+func (h *hpa) status(hpa *autoscalingv2.HorizontalPodAutoscaler) (*HPAStatus, error) {
+	status := &HPAStatus{
+		Name:            hpa.Name,
+		Namespace:       hpa.Namespace,
+		Target:          hpa.Spec.ScaleTargetRef.Kind + "/" + hpa.Spec.ScaleTargetRef.Name,
+		MinReplicas:     1,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+		LastScaleTime:   hpa.Status.LastScaleTime,
+		Metrics:         []*HPAMetric{},
+		Conditions:      hpa.Status.Conditions,
+	}
+	if hpa.Spec.MinReplicas != nil {
+		status.MinReplicas = *hpa.Spec.MinReplicas
+	}
+	for i, spec := range hpa.Spec.Metrics {
+		var current *autoscalingv2.MetricStatus
+		if i < len(hpa.Status.CurrentMetrics) {
+			current = &hpa.Status.CurrentMetrics[i]
+		}
+		status.Metrics = append(status.Metrics, hpaMetric(spec, current))
+	}
+
+	selector := fields.Set{
+		"involvedObject.name": hpa.Name,
+		"involvedObject.uid":  string(hpa.UID),
+	}.AsSelector().String()
+	eventList, err := K8s.ClientSet.CoreV1().Events(hpa.Namespace).List(context.TODO(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		logger.Error(errors.New("获取hpa " + hpa.Name + "的事件失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取hpa " + hpa.Name + "的事件失败,错误信息 " + err.Error())
+	}
+	status.Events = recentEvents(eventList.Items, hpaRecentEvents)
+	return status, nil
+}
+
+// hpaMetric 将hpa的指标定义和当前值转换为可读的内容
+func hpaMetric(spec autoscalingv2.MetricSpec, current *autoscalingv2.MetricStatus) *HPAMetric {
+	metric := &HPAMetric{Type: string(spec.Type), Current: "<unknown>"}
+	switch spec.Type {
+	case autoscalingv2.ResourceMetricSourceType:
+		metric.Name = string(spec.Resource.Name)
+		metric.Target = formatMetricTarget(spec.Resource.Target)
+		if current != nil && current.Resource != nil {
+			metric.Current = formatMetricValue(current.Resource.Current)
+		}
+	case autoscalingv2.ContainerResourceMetricSourceType:
+		metric.Name = spec.ContainerResource.Container + "/" + string(spec.ContainerResource.Name)
+		metric.Target = formatMetricTarget(spec.ContainerResource.Target)
+		if current != nil && current.ContainerResource != nil {
+			metric.Current = formatMetricValue(current.ContainerResource.Current)
+		}
+	case autoscalingv2.PodsMetricSourceType:
+		metric.Name = spec.Pods.Metric.Name
+		metric.Target = formatMetricTarget(spec.Pods.Target)
+		if current != nil && current.Pods != nil {
+			metric.Current = formatMetricValue(current.Pods.Current)
+		}
+	case autoscalingv2.ObjectMetricSourceType:
+		metric.Name = spec.Object.Metric.Name + " on " + spec.Object.DescribedObject.Kind + "/" + spec.Object.DescribedObject.Name
+		metric.Target = formatMetricTarget(spec.Object.Target)
+		if current != nil && current.Object != nil {
+			metric.Current = formatMetricValue(current.Object.Current)
+		}
+	case autoscalingv2.ExternalMetricSourceType:
+		metric.Name = spec.External.Metric.Name
+		metric.Target = formatMetricTarget(spec.External.Target)
+		if current != nil && current.External != nil {
+			metric.Current = formatMetricValue(current.External.Current)
+		}
+	}
+	return metric
+}
+
+// formatMetricTarget 格式化指标的目标值，使用率显示为百分比，平均值加上(avg)
+func formatMetricTarget(target autoscalingv2.MetricTarget) string {
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String() + " (avg)"
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return "<unset>"
+}
+
+// formatMetricValue 格式化指标的当前值，使用率同时显示平均值，如 45% (90m)
+func formatMetricValue(value autoscalingv2.MetricValueStatus) string {
+	switch {
+	case value.AverageUtilization != nil && value.AverageValue != nil:
+		return fmt.Sprintf("%d%% (%s)", *value.AverageUtilization, value.AverageValue.String())
+	case value.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *value.AverageUtilization)
+	case value.AverageValue != nil:
+		return value.AverageValue.String() + " (avg)"
+	case value.Value != nil:
+		return value.Value.String()
+	}
+	return "<unknown>"
+}
+
+func (h *hpa) toCells(std []autoscalingv2.HorizontalPodAutoscaler) []DataCell {
+	cells := make([]DataCell, len(std))
+	for i := range std {
+		cells[i] = hpaCell(std[i])
+	}
+	return cells
+}
+
+func (h *hpa) fromCells(cells []DataCell) []autoscalingv2.HorizontalPodAutoscaler {
+	hpas := make([]autoscalingv2.HorizontalPodAutoscaler, len(cells))
+	for i := range cells {
+		hpas[i] = autoscalingv2.HorizontalPodAutoscaler(cells[i].(hpaCell))
+	}
+	return hpas
+}
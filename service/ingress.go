@@ -16,6 +16,7 @@ type ingress struct{}
 type IngressesResp struct {
 	Items []nwv1.Ingress `json:"items"`
 	Total int            `json:"total"`
+	ListMeta
 }
 
 // IngressCreate 定义IngressCreate结构体,用于创建service需要参数属性的定义
@@ -35,9 +36,15 @@ type HttpPath struct {
 }
 
 // GetIngresses 获取ingress列表、支持过滤、排序、分页
-func (i *ingress) GetIngresses(filterName, namespace string, limit, page int) (ingressesResp *IngressesResp, err error) {
+func (i *ingress) GetIngresses(filterName, namespace string, limit, page int, selectParams *SelectParams) (ingressesResp *IngressesResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取ingressList类型的ingress列表
-	ingressList, err := K8s.ClientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
+	ingressList, err := K8s.ClientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取Ingress列表失败,错误信息," + err.Error()))
 		return nil, errors.New("获取Ingress列表失败,错误信息," + err.Error())
@@ -45,26 +52,19 @@ func (i *ingress) GetIngresses(filterName, namespace string, limit, page int) (i
 	// 将ingressList中的ingress列表(Items),放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: i.toCells(ingressList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{
-				Name: filterName,
-			},
-			Paginate: &PaginateQuery{
-				Limit: limit,
-				Page:  page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(ingressList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的ingress列表转为v1.ingress列表
 	ingresses := i.fromCells(data.GenericDataList)
 
 	return &IngressesResp{
-		Items: ingresses,
-		Total: total,
+		Items:    ingresses,
+		Total:    total,
+		ListMeta: newListMeta(ingressList.ListMeta),
 	}, nil
 }
 
@@ -157,12 +157,30 @@ func (i *ingress) UpdateIngress(namespace, content string) (err error) {
 	}
 	_, err = K8s.ClientSet.NetworkingV1().Ingresses(namespace).Update(context.TODO(), ingress, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "Ingress", nwv1.SchemeGroupVersion.WithResource("ingresses"), namespace, ingress); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新ingress失败，错误信息," + err.Error()))
 		return errors.New("更新ingress失败，错误信息," + err.Error())
 	}
 	return nil
 }
 
+// PatchIngress 对ingress打补丁，patchType支持json、merge、strategic，默认为merge
+func (i *ingress) PatchIngress(ingressName, namespace, patchType, content string) (ingress *nwv1.Ingress, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	ingress, err = K8s.ClientSet.NetworkingV1().Ingresses(namespace).Patch(context.TODO(), ingressName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改ingress " + ingressName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改ingress " + ingressName + "失败,错误信息 " + err.Error())
+	}
+	return ingress, nil
+}
+
 func (i *ingress) toCells(std []nwv1.Ingress) []DataCell {
 	cells := make([]DataCell, len(std))
 	for i := range std {
@@ -3,7 +3,13 @@ package service
 import (
 	"NativeSphere/config"
 	"github.com/wonderivan/logger"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -15,6 +21,16 @@ var K8s k8s
 // 生产k8s结构体方法
 type k8s struct {
 	ClientSet *kubernetes.Clientset
+	// Config 保存初始化时的rest配置，供exec、port-forward等需要升级连接的场景复用
+	Config *rest.Config
+	// DynamicClient 用于操作任意GroupVersionResource的资源，包括CRD
+	DynamicClient dynamic.Interface
+	// MetadataClient 只获取资源的metadata，用于全局搜索等不需要完整对象的场景
+	MetadataClient metadata.Interface
+	// Discovery 带缓存的discovery client，用于获取集群支持的资源列表
+	Discovery discovery.CachedDiscoveryInterface
+	// RESTMapper 基于discovery的GVR/GVK映射，缓存填充后不会自动刷新，遇到未知资源时需要调用Reset后重试，见restMapping、resourceFor
+	RESTMapper *restmapper.DeferredDiscoveryRESTMapper
 }
 
 // Init 初始化k8s
@@ -31,4 +47,23 @@ func (k *k8s) Init() {
 		logger.Info("初始化k8s clientSet成功!")
 	}
 	k.ClientSet = clientSet
+	k.Config = conf
+
+	dynamicClient, err := dynamic.NewForConfig(conf)
+	if err != nil {
+		logger.Error("初始化k8s dynamicClient失败， " + err.Error())
+	} else {
+		logger.Info("初始化k8s dynamicClient成功!")
+	}
+	k.DynamicClient = dynamicClient
+
+	metadataClient, err := metadata.NewForConfig(conf)
+	if err != nil {
+		logger.Error("初始化k8s metadataClient失败， " + err.Error())
+	}
+	k.MetadataClient = metadataClient
+	if clientSet != nil {
+		k.Discovery = memory.NewMemCacheClient(clientSet.Discovery())
+		k.RESTMapper = restmapper.NewDeferredDiscoveryRESTMapper(k.Discovery)
+	}
 }
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/wonderivan/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// Job 实例化job结构体
+var Job job
+
+// 定义job结构体
+type job struct{}
+
+// job的运行状态
+const (
+	JobRunning   = "Running"
+	JobComplete  = "Complete"
+	JobFailed    = "Failed"
+	JobSuspended = "Suspended"
+)
+
+// JobsResp 定义job列表的返回内容
+type JobsResp struct {
+	Items []batchv1.Job `json:"items"`
+	Total int           `json:"total"`
+	ListMeta
+}
+
+// JobTemplate 定义job及cronJob中pod模板的表单参数
+// RestartPolicy可选值为OnFailure、Never，默认为OnFailure，其余为空时使用k8s的默认值
+type JobTemplate struct {
+	Image                   string   `json:"image"`
+	Command                 []string `json:"command"`
+	Args                    []string `json:"args"`
+	Cpu                     string   `json:"cpu"`
+	Memory                  string   `json:"memory"`
+	RestartPolicy           string   `json:"restartPolicy"`
+	Completions             *int32   `json:"completions"`
+	Parallelism             *int32   `json:"parallelism"`
+	BackoffLimit            *int32   `json:"backoffLimit"`
+	ActiveDeadlineSeconds   *int64   `json:"activeDeadlineSeconds"`
+	TTLSecondsAfterFinished *int32   `json:"ttlSecondsAfterFinished"`
+}
+
+// JobCreate 定义JobCreate结构体，用于创建job需要的参数属性的定义
+type JobCreate struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Label     map[string]string `json:"label"`
+	JobTemplate
+}
+
+// JobCleanResp 定义清理已结束job的返回内容
+type JobCleanResp struct {
+	Deleted []string `json:"deleted"`
+	Total   int      `json:"total"`
+}
+
+// GetJobs 获取job列表，支持过滤、排序、分页，可以通过status字段按运行状态过滤
+func (j *job) GetJobs(filterName, namespace string, limit, page int, selectParams *SelectParams) (jobsResp *JobsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	jobList, err := K8s.ClientSet.BatchV1().Jobs(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
+	if err != nil {
+		logger.Error(errors.New("获取job列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取job列表失败,错误信息 " + err.Error())
+	}
+	selectableData := &dataSelector{
+		GenericDataList: j.toCells(jobList.Items),
+		DataSelectQuery: dataSelectQuery,
+	}
+	filtered := selectableData.Filter()
+	total := filtered.Total(jobList.RemainingItemCount)
+	data := filtered.Sort().Paginate()
+
+	return &JobsResp{
+		Items:    j.fromCells(data.GenericDataList),
+		Total:    total,
+		ListMeta: newListMeta(jobList.ListMeta),
+	}, nil
+}
+
+// GetJobDetail 获取job详情
+func (j *job) GetJobDetail(jobName, namespace string) (job *batchv1.Job, err error) {
+	job, err = K8s.ClientSet.BatchV1().Jobs(namespace).Get(context.TODO(), jobName, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取job " + jobName + "详情失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取job " + jobName + "详情失败,错误信息 " + err.Error())
+	}
+	return job, nil
+}
+
+// CreateJob 创建job，接受JobCreate对象
+func (j *job) CreateJob(data *JobCreate) (err error) {
+	spec, err := newJobSpec(data.Name, data.Label, &data.JobTemplate)
+	if err != nil {
+		return err
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      data.Name,
+			Namespace: data.Namespace,
+			Labels:    data.Label,
+		},
+		Spec: spec,
+	}
+	_, err = K8s.ClientSet.BatchV1().Jobs(data.Namespace).Create(context.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("创建job " + data.Name + "失败,错误信息 " + err.Error()))
+		return errors.New("创建job " + data.Name + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// DeleteJob 删除job，batch/v1的job默认会保留pod，这里指定Background使pod一并删除
+func (j *job) DeleteJob(jobName, namespace string) (err error) {
+	err = K8s.ClientSet.BatchV1().Jobs(namespace).Delete(context.TODO(), jobName, backgroundDeleteOptions())
+	if err != nil {
+		logger.Error(errors.New("删除job " + jobName + "失败,错误信息 " + err.Error()))
+		return errors.New("删除job " + jobName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// CleanFinishedJobs 清理namespace下已结束的job
+// status为Complete或Failed时只清理对应状态的job，为空时两者都清理
+// olderThan大于0时只清理结束时间早于olderThan秒之前的job
+// cronJob创建的job由cronJob的历史保留策略管理，includeCronJob为true时才一并清理
+// 删除中途失败时返回已删除的job以及错误信息
+func (j *job) CleanFinishedJobs(namespace, status string, olderThan int64, includeCronJob bool) (cleanResp *JobCleanResp, err error) {
+	if status != "" && status != JobComplete && status != JobFailed {
+		logger.Error(errors.New("不支持的job状态 " + status + ",可选值为Complete、Failed"))
+		return nil, errors.New("不支持的job状态 " + status + ",可选值为Complete、Failed")
+	}
+	jobList, err := K8s.ClientSet.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取job列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取job列表失败,错误信息 " + err.Error())
+	}
+	deadline := time.Now().Add(-time.Duration(olderThan) * time.Second)
+	cleanResp = &JobCleanResp{Deleted: []string{}}
+	for _, item := range jobList.Items {
+		jobStatus, finishedAt := jobStatusOf(&item)
+		if jobStatus != JobComplete && jobStatus != JobFailed {
+			continue
+		}
+		if status != "" && jobStatus != status {
+			continue
+		}
+		if olderThan > 0 && finishedAt.After(deadline) {
+			continue
+		}
+		if controllerRef := metav1.GetControllerOf(&item); !includeCronJob && controllerRef != nil && controllerRef.Kind == "CronJob" {
+			continue
+		}
+		if err = j.DeleteJob(item.Name, item.Namespace); err != nil {
+			break
+		}
+		cleanResp.Deleted = append(cleanResp.Deleted, item.Name)
+	}
+	cleanResp.Total = len(cleanResp.Deleted)
+	return cleanResp, err
+}
+
+// jobStatusOf 获取job的运行状态，已结束的job同时返回结束时间
+func jobStatusOf(job *batchv1.Job) (string, time.Time) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return JobComplete, condition.LastTransitionTime.Time
+		case batchv1.JobFailed:
+			return JobFailed, condition.LastTransitionTime.Time
+		}
+	}
+	if job.Spec.Suspend != nil && *job.Spec.Suspend {
+		return JobSuspended, time.Time{}
+	}
+	return JobRunning, time.Time{}
+}
+
+// newJobSpec 根据表单参数组装job的spec，job的selector由apiserver自动生成
+func newJobSpec(name string, label map[string]string, template *JobTemplate) (batchv1.JobSpec, error) {
+	restartPolicy := corev1.RestartPolicy(template.RestartPolicy)
+	switch restartPolicy {
+	case "":
+		restartPolicy = corev1.RestartPolicyOnFailure
+	case corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever:
+	default:
+		logger.Error(errors.New("不支持的restartPolicy " + template.RestartPolicy + ",可选值为OnFailure、Never"))
+		return batchv1.JobSpec{}, errors.New("不支持的restartPolicy " + template.RestartPolicy + ",可选值为OnFailure、Never")
+	}
+	resources, err := parseResources(template.Cpu, template.Memory)
+	if err != nil {
+		return batchv1.JobSpec{}, err
+	}
+	container := corev1.Container{
+		Name:    name,
+		Image:   template.Image,
+		Command: template.Command,
+		Args:    template.Args,
+	}
+	if len(resources) > 0 {
+		container.Resources.Limits = resources
+		container.Resources.Requests = resources.DeepCopy()
+	}
+	return batchv1.JobSpec{
+		Completions:             template.Completions,
+		Parallelism:             template.Parallelism,
+		BackoffLimit:            template.BackoffLimit,
+		ActiveDeadlineSeconds:   template.ActiveDeadlineSeconds,
+		TTLSecondsAfterFinished: template.TTLSecondsAfterFinished,
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: label,
+			},
+			Spec: corev1.PodSpec{
+				Containers:    []corev1.Container{container},
+				RestartPolicy: restartPolicy,
+			},
+		},
+	}, nil
+}
+
+// backgroundDeleteOptions 删除时在后台级联删除子资源
+func backgroundDeleteOptions() metav1.DeleteOptions {
+	propagation := metav1.DeletePropagationBackground
+	return metav1.DeleteOptions{PropagationPolicy: &propagation}
+}
+
+func (j *job) toCells(std []batchv1.Job) []DataCell {
+	cells := make([]DataCell, len(std))
+	for i := range std {
+		cells[i] = jobCell(std[i])
+	}
+	return cells
+}
+
+func (j *job) fromCells(cells []DataCell) []batchv1.Job {
+	jobs := make([]batchv1.Job, len(cells))
+	for i := range cells {
+		jobs[i] = batchv1.Job(cells[i].(jobCell))
+	}
+	return jobs
+}
@@ -18,6 +18,7 @@ type namespace struct{}
 type NamespaceResp struct {
 	Items []corev1.Namespace `json:"items"`
 	Total int                `json:"total"`
+	ListMeta
 }
 
 // NamespaceCreate 定义namespace结构体，用于创建namespace需要的参数属性的定义
@@ -28,9 +29,15 @@ type NamespaceCreate struct {
 }
 
 // GetNamespaces 获取namespace列表、支持过滤、排序和分页
-func (n *namespace) GetNamespaces(filterName string, limit, page int) (namespaceResp *NamespaceResp, err error) {
+func (n *namespace) GetNamespaces(filterName string, limit, page int, selectParams *SelectParams) (namespaceResp *NamespaceResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取namespaceList类型的namespace列表
-	namespaceList, err := K8s.ClientSet.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	namespaceList, err := K8s.ClientSet.CoreV1().Namespaces().List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取namespace列表失败,错误信息" + err.Error()))
 		return nil, errors.New("获取namespace列表失败,错误信息" + err.Error())
@@ -38,26 +45,19 @@ func (n *namespace) GetNamespaces(filterName string, limit, page int) (namespace
 	// 将namespaceList中的namespace列表（items）,放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: n.toCells(namespaceList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{
-				Name: filterName,
-			},
-			Paginate: &PaginateQuery{
-				Limit: limit,
-				Page:  page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(namespaceList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的namespace列表转为v1.namespace列表
 	namespaces := n.fromCells(data.GenericDataList)
 
 	return &NamespaceResp{
-		Items: namespaces,
-		Total: total,
+		Items:    namespaces,
+		Total:    total,
+		ListMeta: newListMeta(namespaceList.ListMeta),
 	}, nil
 }
 
@@ -71,6 +71,21 @@ func (n *namespace) GetNamespaceDetail(namespaceName string) (namespace *corev1.
 	return namespace, nil
 }
 
+// PatchNamespace 对namespace打补丁，patchType支持json、merge、strategic，默认为merge
+func (n *namespace) PatchNamespace(namespaceName, patchType, content string) (namespace *corev1.Namespace, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	namespace, err = K8s.ClientSet.CoreV1().Namespaces().Patch(context.TODO(), namespaceName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改namespace " + namespaceName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改namespace " + namespaceName + "失败,错误信息 " + err.Error())
+	}
+	return namespace, nil
+}
+
 // DeleteNamespace 删除namespace
 func (n *namespace) DeleteNamespace(namespaceName string) (err error) {
 	err = K8s.ClientSet.CoreV1().Namespaces().Delete(context.TODO(), namespaceName, metav1.DeleteOptions{})
@@ -15,12 +15,19 @@ type node struct{}
 type NodesResp struct {
 	Items []corev1.Node `json:"items"`
 	Total int           `json:"total"`
+	ListMeta
 }
 
 // GetNodes 获取node列表，支持过滤、排序、分页
-func (n *node) GetNodes(filterName string, limit, page int) (nodesResp *NodesResp, err error) {
+func (n *node) GetNodes(filterName string, limit, page int, selectParams *SelectParams) (nodesResp *NodesResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	//获取nodeList类型的node列表
-	nodeList, err := K8s.ClientSet.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	nodeList, err := K8s.ClientSet.CoreV1().Nodes().List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取Node列表失败, " + err.Error()))
 		return nil, errors.New("获取Node列表失败, " + err.Error())
@@ -28,25 +35,20 @@ func (n *node) GetNodes(filterName string, limit, page int) (nodesResp *NodesRes
 	//将nodeList中的node列表(Items)，放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: n.toCells(nodeList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{Name: filterName},
-			Paginate: &PaginateQuery{
-				Limit: limit,
-				Page:  page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(nodeList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	//将[]DataCell类型的node列表转为v1.node列表
 	nodes := n.fromCells(data.GenericDataList)
 
 	return &NodesResp{
-		Items: nodes,
-		Total: total,
+		Items:    nodes,
+		Total:    total,
+		ListMeta: newListMeta(nodeList.ListMeta),
 	}, nil
 }
 
@@ -61,6 +63,21 @@ func (n *node) GetNodeDetail(nodeName string) (node *corev1.Node, err error) {
 	return node, nil
 }
 
+// PatchNode 对node打补丁，patchType支持json、merge、strategic，默认为merge
+func (n *node) PatchNode(nodeName, patchType, content string) (node *corev1.Node, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	node, err = K8s.ClientSet.CoreV1().Nodes().Patch(context.TODO(), nodeName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改node " + nodeName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改node " + nodeName + "失败,错误信息 " + err.Error())
+	}
+	return node, nil
+}
+
 func (n *node) toCells(std []corev1.Node) []DataCell {
 	cells := make([]DataCell, len(std))
 	for i := range std {
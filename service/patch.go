@@ -0,0 +1,72 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// 补丁校验，各资源的patch接口在提交到apiserver之前校验补丁类型和补丁内容
+// strategic merge patch依赖内置资源的结构定义(patchStrategy、patchMergeKey)，CRD及聚合api的资源只支持json和merge
+
+// CheckPatch 校验补丁类型及补丁内容，json patch的内容为操作数组，merge和strategic的内容为json对象
+func CheckPatch(patchType, content string) error {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	if content == "" {
+		logger.Error(errors.New("补丁内容不能为空"))
+		return errors.New("补丁内容不能为空")
+	}
+	if pt == types.JSONPatchType {
+		var operations []map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &operations); err != nil {
+			logger.Error(errors.New("json patch的内容必须为操作数组,错误信息 " + err.Error()))
+			return errors.New("json patch的内容必须为操作数组,错误信息 " + err.Error())
+		}
+		for i, operation := range operations {
+			if _, ok := operation["op"]; !ok {
+				logger.Error(fmt.Errorf("json patch的第%d个操作缺少op", i+1))
+				return fmt.Errorf("json patch的第%d个操作缺少op", i+1)
+			}
+		}
+		return nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &object); err != nil {
+		logger.Error(errors.New(patchType + "补丁的内容必须为json对象,错误信息 " + err.Error()))
+		return errors.New(patchType + "补丁的内容必须为json对象,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// CheckPatchType 校验资源类型是否支持补丁类型，strategic只支持内置资源
+func (r *dynamicResource) CheckPatchType(resourceType *ResourceType, patchType string) error {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	if pt != types.StrategicMergePatchType {
+		return nil
+	}
+	gvr, _, err := r.resolve(resourceType)
+	if err != nil {
+		return err
+	}
+	gvk, err := K8s.RESTMapper.KindFor(gvr)
+	if err != nil {
+		logger.Error(errors.New("获取资源类型 " + gvr.String() + "的Kind失败,错误信息 " + err.Error()))
+		return errors.New("获取资源类型 " + gvr.String() + "的Kind失败,错误信息 " + err.Error())
+	}
+	if !scheme.Scheme.Recognizes(gvk) {
+		logger.Error(errors.New(gvk.Kind + "不是内置资源,不支持strategic补丁,请使用json或merge"))
+		return errors.New(gvk.Kind + "不是内置资源,不支持strategic补丁,请使用json或merge")
+	}
+	return nil
+}
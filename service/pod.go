@@ -10,6 +10,8 @@ import (
 	"io"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"time"
 )
 
 // Pod 定义pod类型和Pod对象，用于包外的调用(包是指service目录)，例如Controller
@@ -21,6 +23,7 @@ type pod struct{}
 type PodsResp struct {
 	Total int          `json:"total"`
 	Items []corev1.Pod `json:"items"`
+	ListMeta
 }
 
 // PodsNp 定义PodsNp类型，用于返回namespace中pod的数量
@@ -29,13 +32,29 @@ type PodsNp struct {
 	PodNum    int    `json:"podNum"`
 }
 
+// PodDebugCreate 定义PodDebugCreate结构体，用于向运行中的pod注入临时调试容器
+// TargetContainer为共享进程命名空间的目标容器，ContainerName为空时自动生成
+type PodDebugCreate struct {
+	PodName         string `json:"pod_name"`
+	Namespace       string `json:"namespace"`
+	Image           string `json:"image"`
+	TargetContainer string `json:"target_container"`
+	ContainerName   string `json:"container_name"`
+}
+
 // GetPods 获取pod列表，支持过滤、排序、分页
-func (p *pod) GetPods(filterName, namespace string, limit, page int) (podsResp *PodsResp, err error) {
+func (p *pod) GetPods(filterName, namespace string, limit, page int, selectParams *SelectParams) (podsResp *PodsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	//获取podList类型的pod列表
 	//context.TODO()用于声明一个空的context上下文，用于List方法内设置这个请求的超时(源码)，这里 的常用用法
 	//metav1.ListOptions{}用于过滤List数据，如使用label，field等
 	//kubectl get services --all-namespaces --field-seletor metadata.namespace != default
-	podList, err := K8s.ClientSet.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	podList, err := K8s.ClientSet.CoreV1().Pods(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		// 打印日志，方便拍错
 		logger.Info("获取Pod列表失败，" + err.Error()) //logger用于打印日志
@@ -46,17 +65,11 @@ func (p *pod) GetPods(filterName, namespace string, limit, page int) (podsResp *
 	// 实例化dataSelector结构体，组装数据
 	selectableData := &dataSelector{
 		GenericDataList: p.toCells(podList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{filterName},
-			Paginate: &PaginateQuery{
-				limit,
-				page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	// 先过滤
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(podList.RemainingItemCount)
 	// 排序和分页
 	data := filtered.Sort().Paginate()
 
@@ -64,8 +77,9 @@ func (p *pod) GetPods(filterName, namespace string, limit, page int) (podsResp *
 	pods := p.fromCells(data.GenericDataList)
 
 	return &PodsResp{
-		Items: pods,
-		Total: total,
+		Items:    pods,
+		Total:    total,
+		ListMeta: newListMeta(podList.ListMeta),
 	}, nil
 }
 
@@ -102,12 +116,30 @@ func (p *pod) UpdatePod(podName, namespace, content string) (err error) {
 	// 执行更新pod操作
 	_, err = K8s.ClientSet.CoreV1().Pods(namespace).Update(context.TODO(), pod, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "Pod", corev1.SchemeGroupVersion.WithResource("pods"), namespace, pod); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新pod " + podName + "失败，错误信息 " + err.Error()))
 		return errors.New("更新pod " + podName + "失败，错误信息 " + err.Error())
 	}
 	return err
 }
 
+// PatchPod 对pod打补丁，patchType支持json、merge、strategic，默认为merge
+func (p *pod) PatchPod(podName, namespace, patchType, content string) (pod *corev1.Pod, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	pod, err = K8s.ClientSet.CoreV1().Pods(namespace).Patch(context.TODO(), podName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改pod " + podName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改pod " + podName + "失败,错误信息 " + err.Error())
+	}
+	return pod, nil
+}
+
 // GetPodContainer 获取pod中的容器名称
 func (p *pod) GetPodContainer(podName, namespace string) (containers []string, err error) {
 	//获取pod详情
@@ -119,9 +151,99 @@ func (p *pod) GetPodContainer(podName, namespace string) (containers []string, e
 	for _, container := range pod.Spec.Containers {
 		containers = append(containers, container.Name)
 	}
+	// 临时调试容器同样可以打开终端
+	for _, container := range pod.Spec.EphemeralContainers {
+		containers = append(containers, container.Name)
+	}
 	return containers, nil
 }
 
+// CreateDebugContainer 通过ephemeralcontainers子资源向运行中的pod注入临时调试容器
+// 等待容器运行后返回容器名，随后可使用终端(WsHandler)进入该容器
+func (p *pod) CreateDebugContainer(data *PodDebugCreate) (containerName string, err error) {
+	pod, err := p.GetPodDetail(data.PodName, data.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if data.Image == "" {
+		data.Image = config.DebugContainerImage
+	}
+	containerName = data.ContainerName
+	if containerName == "" {
+		containerName = "debugger-" + utilrand.String(5)
+	}
+	// 校验容器名是否与已有容器冲突，以及目标容器是否存在
+	targetExists := data.TargetContainer == ""
+	for _, container := range pod.Spec.Containers {
+		if container.Name == containerName {
+			return "", errors.New("容器 " + containerName + "已存在于pod " + data.PodName + "中")
+		}
+		if container.Name == data.TargetContainer {
+			targetExists = true
+		}
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == containerName {
+			return "", errors.New("容器 " + containerName + "已存在于pod " + data.PodName + "中")
+		}
+	}
+	if !targetExists {
+		return "", errors.New("目标容器 " + data.TargetContainer + "不存在于pod " + data.PodName + "中")
+	}
+
+	// 组装临时容器，Stdin和TTY需要打开，否则终端无法交互
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     containerName,
+			Image:                    data.Image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: data.TargetContainer,
+	})
+	_, err = K8s.ClientSet.CoreV1().Pods(data.Namespace).UpdateEphemeralContainers(context.TODO(), data.PodName, pod, metav1.UpdateOptions{})
+	if err != nil {
+		logger.Error(errors.New("pod " + data.PodName + "注入调试容器失败,错误信息 " + err.Error()))
+		return "", errors.New("pod " + data.PodName + "注入调试容器失败,错误信息 " + err.Error())
+	}
+
+	// 轮询等待调试容器运行
+	deadline := time.Now().Add(config.DebugContainerTimeout)
+	for time.Now().Before(deadline) {
+		pod, err = p.GetPodDetail(data.PodName, data.Namespace)
+		if err != nil {
+			return "", err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != containerName {
+				continue
+			}
+			if status.State.Running != nil {
+				return containerName, nil
+			}
+			if status.State.Terminated != nil {
+				return "", errors.New("调试容器 " + containerName + "已退出," + status.State.Terminated.Reason)
+			}
+			if waiting := status.State.Waiting; waiting != nil && debugContainerFailedReasons[waiting.Reason] {
+				return "", errors.New("调试容器 " + containerName + "启动失败," + waiting.Reason + ": " + waiting.Message)
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return "", errors.New("等待调试容器 " + containerName + "运行超时")
+}
+
+// debugContainerFailedReasons 调试容器处于这些等待原因时不会自行恢复，直接返回失败而不是等到超时
+var debugContainerFailedReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
 // GetPodLog 获取pod中容器的日志
 func (p *pod) GetPodLog(containerName, podName, namespace string) (log string, err error) {
 	//设置日志的配置，容器名、tail的行数
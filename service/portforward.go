@@ -0,0 +1,226 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/wonderivan/logger"
+	"io"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PortForward 定义PortForward全局变量
+var PortForward portForward
+
+// 定义portForward结构体
+type portForward struct{}
+
+// PortForwardAudit 定义port-forward会话的审计信息，会话结束时输出到日志
+type PortForwardAudit struct {
+	Username   string    `json:"username"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Namespace  string    `json:"namespace"`
+	PodName    string    `json:"podName"`
+	Port       int       `json:"port"`
+	StartTime  time.Time `json:"startTime"`
+	EndTime    time.Time `json:"endTime"`
+	BytesIn    int64     `json:"bytesIn"`
+	BytesOut   int64     `json:"bytesOut"`
+	Error      string    `json:"error"`
+}
+
+// WsHandler 定义port-forward的websocket handler方法
+// 每个websocket连接对应pod端口上的一条tcp连接，websocket中的binary消息即为tcp的原始数据
+// 请求示例: ws://127.0.0.1:8081/ws/portforward?namespace=default&podName=mysql-0&port=3306&token=xxx
+func (p *portForward) WsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		logger.Error("解析参数失败,错误信息," + err.Error())
+		http.Error(w, "解析参数失败,错误信息,"+err.Error(), http.StatusBadRequest)
+		return
+	}
+	namespace := r.Form.Get("namespace")
+	podName := r.Form.Get("podName")
+	port, err := strconv.Atoi(r.Form.Get("port"))
+	if err != nil || port <= 0 || port > 65535 {
+		http.Error(w, "端口参数不合法", http.StatusBadRequest)
+		return
+	}
+	// 身份识别及RBAC校验，需要在升级websocket之前完成，失败时直接返回http错误码
+	username, err := getRequestUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err := checkAccess(username, namespace, "create", "", "pods", "portforward", podName); err != nil {
+		status := http.StatusInternalServerError
+		if IsAccessDenied(err) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	audit := &PortForwardAudit{
+		Username:   username,
+		RemoteAddr: r.RemoteAddr,
+		Namespace:  namespace,
+		PodName:    podName,
+		Port:       port,
+		StartTime:  time.Now(),
+	}
+	// 与apiserver建立port-forward的SPDY连接
+	streamConn, err := p.dial(podName, namespace)
+	if err != nil {
+		audit.Error = err.Error()
+		p.audit(audit)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer streamConn.Close()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("升级websocket协议失败," + err.Error())
+		return
+	}
+	defer conn.Close()
+	logger.Info("port-forward pod: %s, port: %d, namespace: %s, user: %s\n", podName, port, namespace, username)
+
+	err = p.forward(conn, streamConn, port, audit)
+	if err != nil {
+		audit.Error = err.Error()
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()), time.Now().Add(time.Second))
+	}
+	p.audit(audit)
+}
+
+// dial 与pod的portforward子资源建立SPDY连接
+func (p *portForward) dial(podName, namespace string) (httpstream.Connection, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(K8s.Config)
+	if err != nil {
+		logger.Error(errors.New("创建SPDY transport失败,错误信息 " + err.Error()))
+		return nil, errors.New("创建SPDY transport失败,错误信息 " + err.Error())
+	}
+	// 组装POST请求
+	// URL长相: https://192.168.1.11:6443/api/v1/namespaces/default/pods/mysql-0/portforward
+	req := K8s.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+	streamConn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		logger.Error(errors.New("建立port-forward连接失败,错误信息 " + err.Error()))
+		return nil, errors.New("建立port-forward连接失败,错误信息 " + err.Error())
+	}
+	return streamConn, nil
+}
+
+// forward 在websocket和pod端口的data stream之间双向拷贝数据，任意一端关闭后返回
+func (p *portForward) forward(conn *websocket.Conn, streamConn httpstream.Connection, port int, audit *PortForwardAudit) error {
+	// 每条tcp连接需要一对error stream和data stream，requestID用于关联两者
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return errors.New("创建error stream失败,错误信息 " + err.Error())
+	}
+	// error stream只读不写
+	errorStream.Close()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return errors.New("创建data stream失败,错误信息 " + err.Error())
+	}
+	defer dataStream.Close()
+
+	var (
+		once    sync.Once
+		doneErr error
+		done    = make(chan struct{})
+	)
+	finish := func(err error) {
+		once.Do(func() {
+			doneErr = err
+			close(done)
+		})
+	}
+
+	// 读取error stream，pod端口未监听等错误会从这里返回
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		if err != nil {
+			finish(errors.New("读取error stream失败,错误信息 " + err.Error()))
+			return
+		}
+		if len(message) > 0 {
+			finish(fmt.Errorf("转发端口%d失败,错误信息 %s", port, string(message)))
+		}
+	}()
+
+	// pod -> websocket
+	go func() {
+		buf := make([]byte, config.PortForwardBufferSize)
+		for {
+			n, err := dataStream.Read(buf)
+			if n > 0 {
+				atomic.AddInt64(&audit.BytesOut, int64(n))
+				if err := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					finish(nil)
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					finish(errors.New("读取pod端口数据失败,错误信息 " + err.Error()))
+					return
+				}
+				finish(nil)
+				return
+			}
+		}
+	}()
+
+	// websocket -> pod
+	go func() {
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				// 客户端主动断开视为正常结束
+				finish(nil)
+				return
+			}
+			atomic.AddInt64(&audit.BytesIn, int64(len(message)))
+			if _, err := dataStream.Write(message); err != nil {
+				finish(errors.New("写入pod端口数据失败,错误信息 " + err.Error()))
+				return
+			}
+		}
+	}()
+
+	<-done
+	return doneErr
+}
+
+// audit 输出port-forward会话的审计日志
+func (p *portForward) audit(audit *PortForwardAudit) {
+	audit.EndTime = time.Now()
+	logger.Info("[audit] port-forward user: %s, remote: %s, pod: %s/%s:%d, start: %s, duration: %s, in: %d bytes, out: %d bytes, error: %s",
+		audit.Username, audit.RemoteAddr, audit.Namespace, audit.PodName, audit.Port,
+		audit.StartTime.Format(time.RFC3339), audit.EndTime.Sub(audit.StartTime).String(),
+		atomic.LoadInt64(&audit.BytesIn), atomic.LoadInt64(&audit.BytesOut), audit.Error)
+}
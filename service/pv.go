@@ -18,12 +18,19 @@ type pv struct{}
 type PvsResp struct {
 	Items []corev1.PersistentVolume `json:"items"`
 	Total int                       `json:"total"`
+	ListMeta
 }
 
 // GetPvs 获取pv列表、支持过滤、排序、分页
-func (p *pv) GetPvs(filterName string, limit, page int) (PvResp *PvsResp, err error) {
+func (p *pv) GetPvs(filterName string, limit, page int, selectParams *SelectParams) (PvResp *PvsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取PVList类型的pv列表
-	pvList, err := K8s.ClientSet.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+	pvList, err := K8s.ClientSet.CoreV1().PersistentVolumes().List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取pv " + filterName + "失败，错误信息 " + err.Error()))
 		return nil, errors.New("获取pv " + filterName + "失败，错误信息" + err.Error())
@@ -31,26 +38,19 @@ func (p *pv) GetPvs(filterName string, limit, page int) (PvResp *PvsResp, err er
 	// 将PvList中的pv列表(items),放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: p.toCells(pvList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{
-				Name: filterName,
-			},
-			Paginate: &PaginateQuery{
-				limit,
-				page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(pvList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型中的pv列表转为v1.pv列表
 	pvs := p.fromCells(data.GenericDataList)
 
 	return &PvsResp{
-		Items: pvs,
-		Total: total,
+		Items:    pvs,
+		Total:    total,
+		ListMeta: newListMeta(pvList.ListMeta),
 	}, nil
 }
 
@@ -64,6 +64,21 @@ func (p *pv) GetPvDetail(pvName string) (pv *corev1.PersistentVolume, err error)
 	return pv, nil
 }
 
+// PatchPv 对Pv打补丁，patchType支持json、merge、strategic，默认为merge
+func (p *pv) PatchPv(pvName, patchType, content string) (pv *corev1.PersistentVolume, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	pv, err = K8s.ClientSet.CoreV1().PersistentVolumes().Patch(context.TODO(), pvName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改Pv " + pvName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改Pv " + pvName + "失败,错误信息 " + err.Error())
+	}
+	return pv, nil
+}
+
 // DeletePv 删除pv
 func (p *pv) DeletePv(pvName string) (err error) {
 	err = K8s.ClientSet.CoreV1().PersistentVolumes().Delete(context.TODO(), pvName, metav1.DeleteOptions{})
@@ -19,12 +19,19 @@ type pvc struct{}
 type PvcsResp struct {
 	Items []corev1.PersistentVolumeClaim `json:"items"`
 	Total int                            `json:"total"`
+	ListMeta
 }
 
 // GetPvcs 获取pvc列表，支持过滤、排序、分页
-func (p *pvc) GetPvcs(filterName, namespace string, limit, page int) (pvcsResp *PvcsResp, err error) {
+func (p *pvc) GetPvcs(filterName, namespace string, limit, page int, selectParams *SelectParams) (pvcsResp *PvcsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取pvcList类型的pvc列表
-	pvcList, err := K8s.ClientSet.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), metav1.ListOptions{})
+	pvcList, err := K8s.ClientSet.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取pvc列表时报,错误信息 " + err.Error()))
 		return nil, errors.New("获取pvc列表时报,错误信息 \" + err.Error()")
@@ -32,25 +39,18 @@ func (p *pvc) GetPvcs(filterName, namespace string, limit, page int) (pvcsResp *
 	// 将pvcList中的pvc列表放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: p.toCells(pvcList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{
-				Name: filterName,
-			},
-			Paginate: &PaginateQuery{
-				Limit: limit,
-				Page:  page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(pvcList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的pvc列表转为v1.pvc列表
 	pvcs := p.fromCells(data.GenericDataList)
 	return &PvcsResp{
-		Items: pvcs,
-		Total: total,
+		Items:    pvcs,
+		Total:    total,
+		ListMeta: newListMeta(pvcList.ListMeta),
 	}, nil
 }
 
@@ -77,12 +77,30 @@ func (p *pvc) UpdatePvc(namespace, content string) (err error) {
 
 	_, err = K8s.ClientSet.CoreV1().PersistentVolumeClaims(namespace).Update(context.TODO(), pvc, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "PersistentVolumeClaim", corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims"), namespace, pvc); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新Pvc失败, " + err.Error()))
 		return errors.New("更新Pvc失败, " + err.Error())
 	}
 	return nil
 }
 
+// PatchPvc 对Pvc打补丁，patchType支持json、merge、strategic，默认为merge
+func (p *pvc) PatchPvc(pvcName, namespace, patchType, content string) (pvc *corev1.PersistentVolumeClaim, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	pvc, err = K8s.ClientSet.CoreV1().PersistentVolumeClaims(namespace).Patch(context.TODO(), pvcName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改Pvc " + pvcName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改Pvc " + pvcName + "失败,错误信息 " + err.Error())
+	}
+	return pvc, nil
+}
+
 // DeletePvc 删除pvc
 func (p *pvc) DeletePvc(pvcName, namespace string) (err error) {
 	err = K8s.ClientSet.CoreV1().PersistentVolumeClaims(namespace).Delete(context.TODO(), pvcName, metav1.DeleteOptions{})
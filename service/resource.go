@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/wonderivan/logger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"sort"
+	"strings"
+)
+
+// 通用资源接口，基于dynamic client操作discovery中发现的任意资源(包括Job、HPA以及CRD)
+// 新的资源类型无需编写service、cell和路由即可进行增删改查
+
+// Resource 定义Resource全局变量
+var Resource dynamicResource
+
+// 定义dynamicResource结构体，避免与k8s.io/apimachinery/pkg/api/resource包重名
+type dynamicResource struct{}
+
+// ResourceType 定义通用资源接口中的资源类型，resource为资源的复数名称(如deployments)
+// version为空时使用集群的首选版本，核心组的group为空
+type ResourceType struct {
+	Group    string `form:"group" json:"group"`
+	Version  string `form:"version" json:"version"`
+	Resource string `form:"resource" json:"resource"`
+}
+
+// ApiResource 定义集群支持的资源类型信息
+type ApiResource struct {
+	Group      string   `json:"group"`
+	Version    string   `json:"version"`
+	Resource   string   `json:"resource"`
+	Kind       string   `json:"kind"`
+	Namespaced bool     `json:"namespaced"`
+	Verbs      []string `json:"verbs"`
+	ShortNames []string `json:"shortNames"`
+}
+
+// ResourcesResp 定义通用资源列表的返回内容
+type ResourcesResp struct {
+	Items []unstructured.Unstructured `json:"items"`
+	Total int                         `json:"total"`
+	ListMeta
+}
+
+// GetApiResources 获取集群支持的资源类型列表，每种资源只返回首选版本，不包含子资源
+func (r *dynamicResource) GetApiResources() (apiResources []*ApiResource, err error) {
+	// 每次获取时刷新缓存，保证新安装的CRD可见，Reset会同时清空discovery缓存和RESTMapper的映射
+	K8s.RESTMapper.Reset()
+	resourceLists, err := K8s.Discovery.ServerPreferredResources()
+	if err != nil {
+		// 部分group(如不可用的aggregated api)获取失败时，仍然返回其余group的资源
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			logger.Error(errors.New("获取集群资源类型失败,错误信息 " + err.Error()))
+			return nil, errors.New("获取集群资源类型失败,错误信息 " + err.Error())
+		}
+		logger.Warn("部分资源类型获取失败,错误信息 " + err.Error())
+	}
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range resourceList.APIResources {
+			if strings.Contains(apiResource.Name, "/") {
+				continue
+			}
+			apiResources = append(apiResources, &ApiResource{
+				Group:      gv.Group,
+				Version:    gv.Version,
+				Resource:   apiResource.Name,
+				Kind:       apiResource.Kind,
+				Namespaced: apiResource.Namespaced,
+				Verbs:      apiResource.Verbs,
+				ShortNames: apiResource.ShortNames,
+			})
+		}
+	}
+	sort.Slice(apiResources, func(i, j int) bool {
+		if apiResources[i].Group != apiResources[j].Group {
+			return apiResources[i].Group < apiResources[j].Group
+		}
+		return apiResources[i].Resource < apiResources[j].Resource
+	})
+	return apiResources, nil
+}
+
+// GetResources 获取任意资源的列表，支持过滤、排序、分页
+// namespace为空时获取所有namespace下的资源，集群级别的资源忽略namespace
+func (r *dynamicResource) GetResources(resourceType *ResourceType, filterName, namespace string, limit, page int, selectParams *SelectParams) (resourcesResp *ResourcesResp, err error) {
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	client, gvr, err := r.client(resourceType, namespace)
+	if err != nil {
+		return nil, err
+	}
+	resourceList, err := client.List(context.TODO(), dataSelectQuery.ListOptions())
+	if err != nil {
+		logger.Error(errors.New("获取" + gvr.String() + "列表失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取" + gvr.String() + "列表失败,错误信息 " + err.Error())
+	}
+	selectableData := &dataSelector{
+		GenericDataList: r.toCells(resourceList.Items),
+		DataSelectQuery: dataSelectQuery,
+	}
+	filtered := selectableData.Filter()
+	total := filtered.Total(resourceList.GetRemainingItemCount())
+	data := filtered.Sort().Paginate()
+
+	return &ResourcesResp{
+		Items: r.fromCells(data.GenericDataList),
+		Total: total,
+		ListMeta: ListMeta{
+			Continue:           resourceList.GetContinue(),
+			RemainingItemCount: resourceList.GetRemainingItemCount(),
+		},
+	}, nil
+}
+
+// GetResourceDetail 获取任意资源的详情
+func (r *dynamicResource) GetResourceDetail(resourceType *ResourceType, name, namespace string) (obj *unstructured.Unstructured, err error) {
+	client, gvr, err := r.client(resourceType, namespace)
+	if err != nil {
+		return nil, err
+	}
+	obj, err = client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + gvr.String() + " " + name + "详情失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取" + gvr.String() + " " + name + "详情失败,错误信息 " + err.Error())
+	}
+	return obj, nil
+}
+
+// CreateResource 创建任意资源，content为资源的json内容，namespace为空时使用content中的namespace
+func (r *dynamicResource) CreateResource(resourceType *ResourceType, namespace, content string) (obj *unstructured.Unstructured, err error) {
+	obj, err = r.decode(content)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	client, gvr, err := r.client(resourceType, namespace)
+	if err != nil {
+		return nil, err
+	}
+	obj, err = client.Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("创建" + gvr.String() + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("创建" + gvr.String() + "失败,错误信息 " + err.Error())
+	}
+	return obj, nil
+}
+
+// UpdateResource 更新任意资源，content为资源的完整json内容
+func (r *dynamicResource) UpdateResource(resourceType *ResourceType, namespace, content string) (obj *unstructured.Unstructured, err error) {
+	obj, err = r.decode(content)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	client, gvr, err := r.client(resourceType, namespace)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := client.Update(context.TODO(), obj, metav1.UpdateOptions{})
+	if err != nil {
+		if conflict := conflictError(err, obj.GetKind(), gvr, namespace, obj); conflict != nil {
+			return nil, conflict
+		}
+		logger.Error(errors.New("更新" + gvr.String() + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("更新" + gvr.String() + "失败,错误信息 " + err.Error())
+	}
+	return updated, nil
+}
+
+// PatchResource 对任意资源打补丁，patchType支持json、merge、strategic，默认为merge
+// strategic仅支持内置资源，CRD需要使用json或merge
+func (r *dynamicResource) PatchResource(resourceType *ResourceType, name, namespace, patchType, content string) (obj *unstructured.Unstructured, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	client, gvr, err := r.client(resourceType, namespace)
+	if err != nil {
+		return nil, err
+	}
+	obj, err = client.Patch(context.TODO(), name, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改" + gvr.String() + " " + name + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改" + gvr.String() + " " + name + "失败,错误信息 " + err.Error())
+	}
+	return obj, nil
+}
+
+// DeleteResource 删除任意资源
+func (r *dynamicResource) DeleteResource(resourceType *ResourceType, name, namespace string) (err error) {
+	client, gvr, err := r.client(resourceType, namespace)
+	if err != nil {
+		return err
+	}
+	err = client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		logger.Error(errors.New("删除" + gvr.String() + " " + name + "失败,错误信息 " + err.Error()))
+		return errors.New("删除" + gvr.String() + " " + name + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// resourceFor 将不完整的GVR解析为完整的GVR
+// RESTMapper的缓存填充后不会自动刷新，资源类型不存在时重置缓存后重试一次，保证集群中新安装的CRD可以被识别
+func resourceFor(input schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+	gvr, err := K8s.RESTMapper.ResourceFor(input)
+	if meta.IsNoMatchError(err) {
+		K8s.RESTMapper.Reset()
+		gvr, err = K8s.RESTMapper.ResourceFor(input)
+	}
+	return gvr, err
+}
+
+// restMapping 获取GroupKind对应的映射，与resourceFor一样在资源类型不存在时重置缓存后重试一次
+func restMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	mapping, err := K8s.RESTMapper.RESTMapping(gk, versions...)
+	if meta.IsNoMatchError(err) {
+		K8s.RESTMapper.Reset()
+		mapping, err = K8s.RESTMapper.RESTMapping(gk, versions...)
+	}
+	return mapping, err
+}
+
+// resolve 通过RESTMapper将请求中的资源类型解析为完整的GVR，并判断资源是否属于namespace级别
+func (r *dynamicResource) resolve(resourceType *ResourceType) (gvr schema.GroupVersionResource, namespaced bool, err error) {
+	if resourceType.Resource == "" {
+		return gvr, false, errors.New("资源类型不能为空")
+	}
+	input := schema.GroupVersionResource{
+		Group:    resourceType.Group,
+		Version:  resourceType.Version,
+		Resource: strings.ToLower(resourceType.Resource),
+	}
+	gvr, err = resourceFor(input)
+	if err != nil {
+		logger.Error(errors.New("资源类型 " + input.String() + "不存在,错误信息 " + err.Error()))
+		return gvr, false, errors.New("资源类型 " + input.String() + "不存在,错误信息 " + err.Error())
+	}
+	gvk, err := K8s.RESTMapper.KindFor(gvr)
+	if err != nil {
+		logger.Error(errors.New("获取资源类型 " + gvr.String() + "的Kind失败,错误信息 " + err.Error()))
+		return gvr, false, errors.New("获取资源类型 " + gvr.String() + "的Kind失败,错误信息 " + err.Error())
+	}
+	mapping, err := restMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		logger.Error(errors.New("获取资源类型 " + gvr.String() + "的映射失败,错误信息 " + err.Error()))
+		return gvr, false, errors.New("获取资源类型 " + gvr.String() + "的映射失败,错误信息 " + err.Error())
+	}
+	return gvr, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// client 获取资源类型对应的dynamic client，集群级别的资源忽略namespace
+func (r *dynamicResource) client(resourceType *ResourceType, namespace string) (client dynamic.ResourceInterface, gvr schema.GroupVersionResource, err error) {
+	gvr, namespaced, err := r.resolve(resourceType)
+	if err != nil {
+		return nil, gvr, err
+	}
+	if !namespaced {
+		return K8s.DynamicClient.Resource(gvr), gvr, nil
+	}
+	return K8s.DynamicClient.Resource(gvr).Namespace(namespace), gvr, nil
+}
+
+// decode 将json内容反序列化为unstructured对象
+func (r *dynamicResource) decode(content string) (obj *unstructured.Unstructured, err error) {
+	obj = &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON([]byte(content)); err != nil {
+		logger.Error(errors.New("反序列化失败，错误信息 " + err.Error()))
+		return nil, errors.New("反序列化失败，错误信息 " + err.Error())
+	}
+	return obj, nil
+}
+
+// parsePatchType 将请求中的补丁类型转换为k8s的PatchType
+func parsePatchType(patchType string) (types.PatchType, error) {
+	switch patchType {
+	case "json":
+		return types.JSONPatchType, nil
+	case "merge", "":
+		return types.MergePatchType, nil
+	case "strategic":
+		return types.StrategicMergePatchType, nil
+	}
+	return "", errors.New("不支持的补丁类型 " + patchType + ",可选值为json、merge、strategic")
+}
+
+func (r *dynamicResource) toCells(std []unstructured.Unstructured) []DataCell {
+	cells := make([]DataCell, len(std))
+	for i := range std {
+		cells[i] = unstructuredCell(std[i])
+	}
+	return cells
+}
+
+func (r *dynamicResource) fromCells(cells []DataCell) []unstructured.Unstructured {
+	objs := make([]unstructured.Unstructured, len(cells))
+	for i := range cells {
+		objs[i] = unstructured.Unstructured(cells[i].(unstructuredCell))
+	}
+	return objs
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+	"strconv"
+)
+
+// deployment的发布历史及回滚，deployment每次修改pod模板都会生成一个新的ReplicaSet，
+// ReplicaSet的revision注解即为版本号，回滚即将指定版本ReplicaSet的pod模板写回deployment，与kubectl rollout undo一致
+
+// revisionAnnotation deployment及ReplicaSet的版本号注解
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// changeCauseAnnotation 记录修改原因的注解
+const changeCauseAnnotation = "kubernetes.io/change-cause"
+
+// rollbackSkippedAnnotations 回滚时不从ReplicaSet复制到deployment的注解
+var rollbackSkippedAnnotations = map[string]bool{
+	corev1.LastAppliedConfigAnnotation:          true,
+	revisionAnnotation:                          true,
+	"deployment.kubernetes.io/revision-history": true,
+	"deployment.kubernetes.io/desired-replicas": true,
+	"deployment.kubernetes.io/max-replicas":     true,
+	"deprecated.deployment.rollback.to":         true,
+}
+
+// DeploymentRevision 定义deployment的一个历史版本，Current表示deployment当前使用的版本
+type DeploymentRevision struct {
+	Revision          int64       `json:"revision"`
+	ReplicaSet        string      `json:"replicaSet"`
+	ChangeCause       string      `json:"changeCause"`
+	Images            []string    `json:"images"`
+	Replicas          int32       `json:"replicas"`
+	Current           bool        `json:"current"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// DeploymentHistoryResp 定义发布历史的返回内容，按版本号从新到旧排序
+type DeploymentHistoryResp struct {
+	Items []*DeploymentRevision `json:"items"`
+	Total int                   `json:"total"`
+}
+
+// RevisionDiffResp 定义两个版本pod模板的对比结果
+type RevisionDiffResp struct {
+	From int64  `json:"from"`
+	To   int64  `json:"to"`
+	Diff string `json:"diff"`
+}
+
+// GetDeploymentHistory 获取deployment的发布历史
+func (d *deployment) GetDeploymentHistory(deploymentName, namespace string) (historyResp *DeploymentHistoryResp, err error) {
+	deployment, replicaSets, err := d.revisions(deploymentName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	current := revisionOf(deployment.ObjectMeta)
+	historyResp = &DeploymentHistoryResp{Items: []*DeploymentRevision{}}
+	for _, replicaSet := range replicaSets {
+		revision := &DeploymentRevision{
+			Revision:          revisionOf(replicaSet.ObjectMeta),
+			ReplicaSet:        replicaSet.Name,
+			ChangeCause:       replicaSet.Annotations[changeCauseAnnotation],
+			Replicas:          replicaSet.Status.Replicas,
+			CreationTimestamp: replicaSet.CreationTimestamp,
+		}
+		revision.Current = revision.Revision == current
+		for _, container := range replicaSet.Spec.Template.Spec.Containers {
+			revision.Images = append(revision.Images, container.Image)
+		}
+		historyResp.Items = append(historyResp.Items, revision)
+	}
+	historyResp.Total = len(historyResp.Items)
+	return historyResp, nil
+}
+
+// GetRevisionDiff 对比deployment两个版本的pod模板，返回unified diff
+func (d *deployment) GetRevisionDiff(deploymentName, namespace string, from, to int64) (diffResp *RevisionDiffResp, err error) {
+	_, replicaSets, err := d.revisions(deploymentName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	fromReplicaSet, err := findRevision(replicaSets, deploymentName, from)
+	if err != nil {
+		return nil, err
+	}
+	toReplicaSet, err := findRevision(replicaSets, deploymentName, to)
+	if err != nil {
+		return nil, err
+	}
+	fromYaml, err := templateYaml(&fromReplicaSet.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	toYaml, err := templateYaml(&toReplicaSet.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	result, err := unifiedDiff(fromYaml, toYaml, "revision/"+strconv.FormatInt(from, 10), "revision/"+strconv.FormatInt(to, 10))
+	if err != nil {
+		return nil, err
+	}
+	return &RevisionDiffResp{From: from, To: to, Diff: result}, nil
+}
+
+// RollbackDeployment 将deployment回滚到指定版本，revision为0时回滚到上一个版本
+// 返回回滚到的版本号，暂停中的deployment不能回滚
+func (d *deployment) RollbackDeployment(deploymentName, namespace string, revision int64) (rollbackTo int64, err error) {
+	deployment, replicaSets, err := d.revisions(deploymentName, namespace)
+	if err != nil {
+		return 0, err
+	}
+	if deployment.Spec.Paused {
+		logger.Error(errors.New("deployment " + deploymentName + "已暂停,请恢复后再回滚"))
+		return 0, errors.New("deployment " + deploymentName + "已暂停,请恢复后再回滚")
+	}
+	current := revisionOf(deployment.ObjectMeta)
+	if revision == 0 {
+		// 上一个版本为除当前版本外版本号最大的ReplicaSet
+		for _, replicaSet := range replicaSets {
+			if r := revisionOf(replicaSet.ObjectMeta); r != current {
+				revision = r
+				break
+			}
+		}
+		if revision == 0 {
+			logger.Error(errors.New("deployment " + deploymentName + "没有可回滚的版本"))
+			return 0, errors.New("deployment " + deploymentName + "没有可回滚的版本")
+		}
+	}
+	replicaSet, err := findRevision(replicaSets, deploymentName, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	// ReplicaSet的pod模板带有pod-template-hash标签，写回deployment前需要去掉
+	template := replicaSet.Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	if equality.Semantic.DeepEqual(template, &deployment.Spec.Template) {
+		logger.Info("deployment " + deploymentName + "的pod模板与版本" + strconv.FormatInt(revision, 10) + "相同,跳过回滚")
+		return revision, nil
+	}
+	annotations := map[string]string{}
+	for key, value := range deployment.Annotations {
+		annotations[key] = value
+	}
+	for key, value := range replicaSet.Annotations {
+		if !rollbackSkippedAnnotations[key] {
+			annotations[key] = value
+		}
+	}
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "replace", "path": "/spec/template", "value": template},
+		{"op": "replace", "path": "/metadata/annotations", "value": annotations},
+	})
+	if err != nil {
+		logger.Error(errors.New("序列化回滚补丁失败,错误信息 " + err.Error()))
+		return 0, errors.New("序列化回滚补丁失败,错误信息 " + err.Error())
+	}
+	_, err = K8s.ClientSet.AppsV1().Deployments(namespace).Patch(context.TODO(), deploymentName, types.JSONPatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("回滚deployment " + deploymentName + "失败,错误信息 " + err.Error()))
+		return 0, errors.New("回滚deployment " + deploymentName + "失败,错误信息 " + err.Error())
+	}
+	return revision, nil
+}
+
+// revisions 获取deployment及其所有版本的ReplicaSet，按版本号从新到旧排序
+func (d *deployment) revisions(deploymentName, namespace string) (*appsv1.Deployment, []*appsv1.ReplicaSet, error) {
+	deployment, err := d.GetDeploymentDetail(deploymentName, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	replicaSetList, err := K8s.ClientSet.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: formatSelector(deployment.Spec.Selector),
+	})
+	if err != nil {
+		logger.Error(errors.New("获取deployment " + deploymentName + "的ReplicaSet失败,错误信息 " + err.Error()))
+		return nil, nil, errors.New("获取deployment " + deploymentName + "的ReplicaSet失败,错误信息 " + err.Error())
+	}
+	return deployment, ownedReplicaSets(replicaSetList.Items, deployment.UID), nil
+}
+
+// findRevision 查找指定版本号的ReplicaSet
+func findRevision(replicaSets []*appsv1.ReplicaSet, deploymentName string, revision int64) (*appsv1.ReplicaSet, error) {
+	for _, replicaSet := range replicaSets {
+		if revisionOf(replicaSet.ObjectMeta) == revision {
+			return replicaSet, nil
+		}
+	}
+	logger.Error(fmt.Errorf("deployment %s不存在版本%d", deploymentName, revision))
+	return nil, fmt.Errorf("deployment %s不存在版本%d", deploymentName, revision)
+}
+
+// templateYaml 将pod模板序列化为yaml，去掉pod-template-hash标签以免干扰对比
+func templateYaml(template *corev1.PodTemplateSpec) (string, error) {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(template)
+	if err != nil {
+		logger.Error(errors.New("转换pod模板失败,错误信息 " + err.Error()))
+		return "", errors.New("转换pod模板失败,错误信息 " + err.Error())
+	}
+	unstructured.RemoveNestedField(object, "metadata", "labels", appsv1.DefaultDeploymentUniqueLabelKey)
+	unstructured.RemoveNestedField(object, "metadata", "creationTimestamp")
+	data, err := yaml.Marshal(object)
+	if err != nil {
+		logger.Error(errors.New("序列化yaml失败,错误信息 " + err.Error()))
+		return "", errors.New("序列化yaml失败,错误信息 " + err.Error())
+	}
+	return string(data), nil
+}
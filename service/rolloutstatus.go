@@ -0,0 +1,490 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"strings"
+	"time"
+)
+
+// 发布状态及暂停、恢复，支持deployment、statefulset、daemonset
+// 状态判断与kubectl rollout status一致；deployment使用spec.paused暂停，
+// statefulset和daemonset没有暂停字段，暂停时将更新策略改为不自动更新(partition等于副本数或OnDelete)，原策略记录在注解中，恢复时写回
+
+// Rollout 定义Rollout全局变量
+var Rollout rollout
+
+// 定义rollout结构体
+type rollout struct{}
+
+// 发布状态的阶段
+const (
+	RolloutProgressing = "Progressing"
+	RolloutComplete    = "Complete"
+	RolloutFailed      = "Failed"
+	RolloutPaused      = "Paused"
+)
+
+// restartedAtAnnotation 重启时写入pod模板的注解
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// rolloutKinds 支持发布状态的资源类型
+var rolloutKinds = map[string]schema.GroupVersionResource{
+	"deployment":  appsv1.SchemeGroupVersion.WithResource("deployments"),
+	"statefulset": appsv1.SchemeGroupVersion.WithResource("statefulsets"),
+	"daemonset":   appsv1.SchemeGroupVersion.WithResource("daemonsets"),
+}
+
+// RolloutStatus 定义发布状态，Done为true表示发布完成、失败或已暂停，恢复前不会再有变化
+// ProgressDeadlineSeconds只对deployment有效，超过该时间没有进展时deployment的Progressing条件变为ProgressDeadlineExceeded
+type RolloutStatus struct {
+	Kind                    string             `json:"kind"`
+	Name                    string             `json:"name"`
+	Namespace               string             `json:"namespace"`
+	Phase                   string             `json:"phase"`
+	Done                    bool               `json:"done"`
+	Message                 string             `json:"message"`
+	Desired                 int32              `json:"desired"`
+	Updated                 int32              `json:"updated"`
+	Ready                   int32              `json:"ready"`
+	Available               int32              `json:"available"`
+	Generation              int64              `json:"generation"`
+	ObservedGeneration      int64              `json:"observedGeneration"`
+	ProgressDeadlineSeconds *int32             `json:"progressDeadlineSeconds,omitempty"`
+	Conditions              []ConditionSummary `json:"conditions"`
+	ResourceVersion         string             `json:"resourceVersion"`
+}
+
+// GetStatus 获取发布状态，kind可选值为deployment、statefulset、daemonset
+func (r *rollout) GetStatus(kind, name, namespace string) (status *RolloutStatus, err error) {
+	client, kind, err := r.client(kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+	return rolloutStatus(kind, obj)
+}
+
+// WatchStatus 持续获取发布状态，状态变化时调用send，发布完成、失败、ctx取消或超过RolloutStatusTimeout时返回
+func (r *rollout) WatchStatus(ctx context.Context, kind, name, namespace string, send func(status *RolloutStatus) error) (err error) {
+	client, kind, err := r.client(kind, namespace)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.RolloutStatusTimeout)
+	defer cancel()
+	obj, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+	for {
+		status, err := rolloutStatus(kind, obj)
+		if err != nil {
+			return err
+		}
+		if err := send(status); err != nil || status.Done {
+			return err
+		}
+		watcher, err := client.Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: obj.GetResourceVersion(),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error(errors.New("watch " + kind + " " + name + "失败,错误信息 " + err.Error()))
+			return errors.New("watch " + kind + " " + name + "失败,错误信息 " + err.Error())
+		}
+		next, err := r.next(ctx, watcher)
+		watcher.Stop()
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+		if next == nil {
+			// watch连接被apiserver关闭，重新获取对象以免错过变化
+			next, err = client.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+				return errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+			}
+		}
+		obj = next
+	}
+}
+
+// next 等待对象的下一次变化，ctx取消或watch连接关闭时返回nil
+func (r *rollout) next(ctx context.Context, watcher watch.Interface) (*unstructured.Unstructured, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil, nil
+			}
+			switch event.Type {
+			case watch.Deleted:
+				return nil, errors.New("对象已被删除")
+			case watch.Error:
+				return nil, errors.New("watch失败,错误信息 " + fmt.Sprint(event.Object))
+			}
+			if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+				return obj, nil
+			}
+		}
+	}
+}
+
+// Pause 暂停发布
+func (r *rollout) Pause(kind, name, namespace string) (err error) {
+	client, kind, err := r.client(kind, namespace)
+	if err != nil {
+		return err
+	}
+	obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+	if paused, _ := rolloutPaused(kind, obj); paused {
+		logger.Error(errors.New(kind + " " + name + "已处于暂停状态"))
+		return errors.New(kind + " " + name + "已处于暂停状态")
+	}
+
+	var patch map[string]interface{}
+	if kind == "deployment" {
+		patch = map[string]interface{}{"spec": map[string]interface{}{"paused": true}}
+	} else {
+		strategy, _, _ := unstructured.NestedMap(obj.Object, "spec", "updateStrategy")
+		saved, err := json.Marshal(strategy)
+		if err != nil {
+			return errors.New("序列化更新策略失败,错误信息 " + err.Error())
+		}
+		var paused map[string]interface{}
+		if kind == "statefulset" {
+			if strategyType, _, _ := unstructured.NestedString(strategy, "type"); strategyType == string(appsv1.OnDeleteStatefulSetStrategyType) {
+				logger.Error(errors.New("statefulset " + name + "的更新策略为OnDelete,不会自动更新,无需暂停"))
+				return errors.New("statefulset " + name + "的更新策略为OnDelete,不会自动更新,无需暂停")
+			}
+			// partition等于副本数时，所有pod都不会被更新
+			replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+			if !found {
+				replicas = 1
+			}
+			paused = map[string]interface{}{
+				"type":          string(appsv1.RollingUpdateStatefulSetStrategyType),
+				"rollingUpdate": map[string]interface{}{"partition": replicas},
+			}
+		} else {
+			paused = map[string]interface{}{
+				"type":          string(appsv1.OnDeleteDaemonSetStrategyType),
+				"rollingUpdate": nil,
+			}
+		}
+		patch = map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{config.RolloutPausedAnnotation: string(saved)},
+			},
+			"spec": map[string]interface{}{"updateStrategy": paused},
+		}
+	}
+	return r.patch(client, kind, name, "暂停", types.MergePatchType, patch)
+}
+
+// Resume 恢复发布
+func (r *rollout) Resume(kind, name, namespace string) (err error) {
+	client, kind, err := r.client(kind, namespace)
+	if err != nil {
+		return err
+	}
+	obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+	if paused, _ := rolloutPaused(kind, obj); !paused {
+		logger.Error(errors.New(kind + " " + name + "未处于暂停状态"))
+		return errors.New(kind + " " + name + "未处于暂停状态")
+	}
+
+	if kind == "deployment" {
+		patch := map[string]interface{}{"spec": map[string]interface{}{"paused": false}}
+		return r.patch(client, kind, name, "恢复", types.MergePatchType, patch)
+	}
+	// 使用json patch整体替换更新策略，merge patch无法去掉暂停时设置的字段
+	strategy := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(obj.GetAnnotations()[config.RolloutPausedAnnotation]), &strategy); err != nil {
+		logger.Error(errors.New("解析暂停前的更新策略失败,错误信息 " + err.Error()))
+		return errors.New("解析暂停前的更新策略失败,错误信息 " + err.Error())
+	}
+	patch := []map[string]interface{}{
+		{"op": "replace", "path": "/spec/updateStrategy", "value": strategy},
+		{"op": "remove", "path": "/metadata/annotations/" + strings.ReplaceAll(config.RolloutPausedAnnotation, "/", "~1")},
+	}
+	return r.patch(client, kind, name, "恢复", types.JSONPatchType, patch)
+}
+
+// restartPatch 生成重启补丁，与kubectl rollout restart一致，修改pod模板的restartedAt注解触发滚动更新
+func restartPatch() ([]byte, error) {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{restartedAtAnnotation: time.Now().Format(time.RFC3339)},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		logger.Error(errors.New("json序列化数据失败,错误信息 " + err.Error()))
+		return nil, errors.New("json序列化数据失败,错误信息 " + err.Error())
+	}
+	return data, nil
+}
+
+// checkRestartable 重启前检查是否处于暂停状态
+// statefulset、daemonset暂停时updateStrategy被改为partition或OnDelete，重启补丁能提交成功但不会重建pod，需要先恢复
+func (r *rollout) checkRestartable(kind, name, namespace string) error {
+	client, kind, err := r.client(kind, namespace)
+	if err != nil {
+		return err
+	}
+	obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+	if paused, _ := rolloutPaused(kind, obj); paused {
+		logger.Error(errors.New(kind + " " + name + "已暂停,请恢复后再重启"))
+		return errors.New(kind + " " + name + "已暂停,请恢复后再重启")
+	}
+	return nil
+}
+
+// client 获取资源类型对应的dynamic client，返回转换为小写的kind
+func (r *rollout) client(kind, namespace string) (dynamic.ResourceInterface, string, error) {
+	kind = strings.ToLower(kind)
+	gvr, ok := rolloutKinds[kind]
+	if !ok {
+		logger.Error(errors.New("不支持的资源类型 " + kind + ",可选值为deployment、statefulset、daemonset"))
+		return nil, kind, errors.New("不支持的资源类型 " + kind + ",可选值为deployment、statefulset、daemonset")
+	}
+	return K8s.DynamicClient.Resource(gvr).Namespace(namespace), kind, nil
+}
+
+// patch 提交补丁，action用于错误信息
+func (r *rollout) patch(client dynamic.ResourceInterface, kind, name, action string, patchType types.PatchType, patch interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		logger.Error(errors.New("序列化补丁失败,错误信息 " + err.Error()))
+		return errors.New("序列化补丁失败,错误信息 " + err.Error())
+	}
+	_, err = client.Patch(context.TODO(), name, patchType, data, metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New(action + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return errors.New(action + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// rolloutPaused 判断是否处于暂停状态
+func rolloutPaused(kind string, obj *unstructured.Unstructured) (bool, error) {
+	if kind == "deployment" {
+		paused, _, err := unstructured.NestedBool(obj.Object, "spec", "paused")
+		return paused, err
+	}
+	_, ok := obj.GetAnnotations()[config.RolloutPausedAnnotation]
+	return ok, nil
+}
+
+// rolloutStatus 根据对象计算发布状态
+func rolloutStatus(kind string, obj *unstructured.Unstructured) (*RolloutStatus, error) {
+	status := &RolloutStatus{
+		Kind:            kind,
+		Name:            obj.GetName(),
+		Namespace:       obj.GetNamespace(),
+		Generation:      obj.GetGeneration(),
+		Conditions:      []ConditionSummary{},
+		ResourceVersion: obj.GetResourceVersion(),
+	}
+	var err error
+	switch kind {
+	case "deployment":
+		deployment := &appsv1.Deployment{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, deployment); err == nil {
+			deploymentRolloutStatus(status, deployment)
+		}
+	case "statefulset":
+		statefulSet := &appsv1.StatefulSet{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, statefulSet); err == nil {
+			statefulSetRolloutStatus(status, statefulSet)
+		}
+	case "daemonset":
+		daemonSet := &appsv1.DaemonSet{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, daemonSet); err == nil {
+			daemonSetRolloutStatus(status, daemonSet)
+		}
+	}
+	if err != nil {
+		logger.Error(errors.New("转换" + kind + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("转换" + kind + "失败,错误信息 " + err.Error())
+	}
+	// 暂停后发布不会继续，也视为结束
+	if paused, _ := rolloutPaused(kind, obj); paused && status.Phase != RolloutFailed {
+		status.Phase = RolloutPaused
+		status.Message = kind + " " + status.Name + "已暂停," + status.Message
+	}
+	status.Done = status.Phase != RolloutProgressing
+	return status, nil
+}
+
+// deploymentRolloutStatus 计算deployment的发布状态
+func deploymentRolloutStatus(status *RolloutStatus, deployment *appsv1.Deployment) {
+	status.Desired = 1
+	if deployment.Spec.Replicas != nil {
+		status.Desired = *deployment.Spec.Replicas
+	}
+	status.Updated = deployment.Status.UpdatedReplicas
+	status.Ready = deployment.Status.ReadyReplicas
+	status.Available = deployment.Status.AvailableReplicas
+	status.ObservedGeneration = deployment.Status.ObservedGeneration
+	status.ProgressDeadlineSeconds = deployment.Spec.ProgressDeadlineSeconds
+	for _, condition := range deployment.Status.Conditions {
+		status.Conditions = append(status.Conditions, ConditionSummary{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+
+	status.Phase = RolloutProgressing
+	switch {
+	case deployment.Generation > deployment.Status.ObservedGeneration:
+		status.Message = "等待控制器处理最新的修改"
+	case progressDeadlineExceeded(deployment):
+		status.Phase = RolloutFailed
+		status.Message = fmt.Sprintf("超过progressDeadlineSeconds(%d秒)仍未完成发布", *deployment.Spec.ProgressDeadlineSeconds)
+	case deployment.Status.UpdatedReplicas < status.Desired:
+		status.Message = fmt.Sprintf("%d/%d个副本已更新", deployment.Status.UpdatedReplicas, status.Desired)
+	case deployment.Status.Replicas > deployment.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("%d个旧副本等待终止", deployment.Status.Replicas-deployment.Status.UpdatedReplicas)
+	case deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("%d/%d个已更新的副本可用", deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas)
+	default:
+		status.Phase = RolloutComplete
+		status.Message = "发布完成"
+	}
+}
+
+// progressDeadlineExceeded 判断deployment的Progressing条件是否为ProgressDeadlineExceeded
+func progressDeadlineExceeded(deployment *appsv1.Deployment) bool {
+	if deployment.Spec.ProgressDeadlineSeconds == nil {
+		return false
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded"
+		}
+	}
+	return false
+}
+
+// statefulSetRolloutStatus 计算statefulset的发布状态，分区更新时只统计分区内的副本
+func statefulSetRolloutStatus(status *RolloutStatus, statefulSet *appsv1.StatefulSet) {
+	status.Desired = 1
+	if statefulSet.Spec.Replicas != nil {
+		status.Desired = *statefulSet.Spec.Replicas
+	}
+	status.Updated = statefulSet.Status.UpdatedReplicas
+	status.Ready = statefulSet.Status.ReadyReplicas
+	status.Available = statefulSet.Status.AvailableReplicas
+	status.ObservedGeneration = statefulSet.Status.ObservedGeneration
+	for _, condition := range statefulSet.Status.Conditions {
+		status.Conditions = append(status.Conditions, ConditionSummary{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+
+	status.Phase = RolloutProgressing
+	var partition int32
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		partition = *rollingUpdate.Partition
+	}
+	switch {
+	case statefulSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType:
+		status.Phase = RolloutComplete
+		status.Message = "更新策略为OnDelete,pod需要手动删除后才会更新"
+	case statefulSet.Generation > statefulSet.Status.ObservedGeneration:
+		status.Message = "等待控制器处理最新的修改"
+	case statefulSet.Status.ReadyReplicas < status.Desired:
+		status.Message = fmt.Sprintf("%d/%d个副本就绪", statefulSet.Status.ReadyReplicas, status.Desired)
+	case partition > 0 && statefulSet.Status.UpdatedReplicas < status.Desired-partition:
+		status.Message = fmt.Sprintf("分区更新中,%d/%d个副本已更新", statefulSet.Status.UpdatedReplicas, status.Desired-partition)
+	case partition > 0:
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("分区更新完成,序号大于等于%d的%d个副本已更新", partition, statefulSet.Status.UpdatedReplicas)
+	case statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision:
+		status.Message = fmt.Sprintf("%d/%d个副本已更新到版本%s", statefulSet.Status.UpdatedReplicas, status.Desired, statefulSet.Status.UpdateRevision)
+	default:
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("发布完成,%d个副本处于版本%s", statefulSet.Status.CurrentReplicas, statefulSet.Status.CurrentRevision)
+	}
+}
+
+// daemonSetRolloutStatus 计算daemonset的发布状态
+func daemonSetRolloutStatus(status *RolloutStatus, daemonSet *appsv1.DaemonSet) {
+	status.Desired = daemonSet.Status.DesiredNumberScheduled
+	status.Updated = daemonSet.Status.UpdatedNumberScheduled
+	status.Ready = daemonSet.Status.NumberReady
+	status.Available = daemonSet.Status.NumberAvailable
+	status.ObservedGeneration = daemonSet.Status.ObservedGeneration
+	for _, condition := range daemonSet.Status.Conditions {
+		status.Conditions = append(status.Conditions, ConditionSummary{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+
+	status.Phase = RolloutProgressing
+	switch {
+	case daemonSet.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType:
+		status.Phase = RolloutComplete
+		status.Message = "更新策略为OnDelete,pod需要手动删除后才会更新"
+	case daemonSet.Generation > daemonSet.Status.ObservedGeneration:
+		status.Message = "等待控制器处理最新的修改"
+	case daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled:
+		status.Message = fmt.Sprintf("%d/%d个节点上的pod已更新", daemonSet.Status.UpdatedNumberScheduled, daemonSet.Status.DesiredNumberScheduled)
+	case daemonSet.Status.NumberAvailable < daemonSet.Status.DesiredNumberScheduled:
+		status.Message = fmt.Sprintf("%d/%d个已更新的pod可用", daemonSet.Status.NumberAvailable, daemonSet.Status.DesiredNumberScheduled)
+	default:
+		status.Phase = RolloutComplete
+		status.Message = "发布完成"
+	}
+}
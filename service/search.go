@@ -0,0 +1,263 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"context"
+	"errors"
+	"github.com/wonderivan/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 全局搜索，按名称、标签、注解在所有支持的资源类型中查找资源
+// 只通过metadata client获取资源的metadata，避免拉取secret内容等完整对象
+
+// Search 定义Search全局变量
+var Search search
+
+// 定义search结构体
+type search struct{}
+
+// 匹配方式对应的得分，得分越高排序越靠前
+const (
+	scoreExactName   = 100
+	scoreNamePrefix  = 80
+	scoreNameContain = 60
+	scoreLabel       = 40
+	scoreAnnotation  = 20
+)
+
+// searchKind 定义可搜索的资源类型，detail和nameParam用于生成详情接口的链接
+type searchKind struct {
+	kind       string
+	gvr        schema.GroupVersionResource
+	namespaced bool
+	detail     string
+	nameParam  string
+}
+
+// searchKinds 可搜索的资源类型，顺序即为得分相同时分组的展示顺序
+var searchKinds = []searchKind{
+	{"Pod", schema.GroupVersionResource{Version: "v1", Resource: "pods"}, true, "/api/v1/k8s/pod/detail", "pod_name"},
+	{"Deployment", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true, "/api/v1/k8s/deployment/detail", "deployment_name"},
+	{"StatefulSet", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, true, "/api/v1/k8s/statefulset/detail", "statefulset_name"},
+	{"DaemonSet", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, true, "/api/v1/k8s/daemonset/detail", "daemonset_name"},
+	{"Job", schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, true, "/api/v1/k8s/job/detail", "job_name"},
+	{"CronJob", schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, true, "/api/v1/k8s/cronjob/detail", "cronjob_name"},
+	{"HorizontalPodAutoscaler", schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, true, "/api/v1/k8s/hpa/detail", "hpa_name"},
+	{"Service", schema.GroupVersionResource{Version: "v1", Resource: "services"}, true, "/api/v1/k8s/service/detail", "service_name"},
+	{"Ingress", schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, true, "/api/v1/k8s/ingress/detail", "ingressName"},
+	{"ConfigMap", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, true, "/api/v1/k8s/configmap/detail", "configmap_name"},
+	{"Secret", schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, true, "/api/v1/k8s/secret/detail", "secretName"},
+	{"PersistentVolumeClaim", schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, true, "/api/v1/k8s/pvc/detail", "pvc_name"},
+	{"PersistentVolume", schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}, false, "/api/v1/k8s/pv/detail", "pv_name"},
+	{"Node", schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, false, "/api/v1/k8s/node/detail", "node_name"},
+	{"Namespace", schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, false, "/api/v1/k8s/namespace/detail", "namespace_name"},
+}
+
+// SearchResult 定义单个搜索结果
+type SearchResult struct {
+	Kind              string            `json:"kind"`
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Labels            map[string]string `json:"labels"`
+	MatchedBy         string            `json:"matchedBy"`
+	Score             int               `json:"score"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	Link              string            `json:"link"`
+}
+
+// SearchGroup 定义按资源类型分组的搜索结果，Total为截断前的匹配数
+type SearchGroup struct {
+	Kind     string          `json:"kind"`
+	Resource string          `json:"resource"`
+	Total    int             `json:"total"`
+	Items    []*SearchResult `json:"items"`
+	score    int
+	order    int
+}
+
+// SearchResp 定义搜索的返回内容，Errors记录查询失败的资源类型(如无权限)，不影响其他类型的结果
+type SearchResp struct {
+	Keyword string            `json:"keyword"`
+	Total   int               `json:"total"`
+	Groups  []*SearchGroup    `json:"groups"`
+	Errors  map[string]string `json:"errors"`
+}
+
+// Search 在所有支持的资源类型中搜索名称、标签或注解包含keyword的资源
+// namespace为空时搜索所有namespace，kinds为空时搜索所有类型，kinds的值为资源的复数名称，如pods、services
+func (s *search) Search(keyword, namespace string, kinds []string, limit int) (searchResp *SearchResp, err error) {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		logger.Error(errors.New("搜索关键字不能为空"))
+		return nil, errors.New("搜索关键字不能为空")
+	}
+	if limit <= 0 {
+		limit = config.SearchLimitPerKind
+	}
+	targets, err := s.kinds(kinds)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), config.SearchTimeout)
+	defer cancel()
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+	searchResp = &SearchResp{Keyword: keyword, Errors: map[string]string{}}
+	for i, target := range targets {
+		// 集群级别的资源只在未指定namespace时搜索
+		if namespace != "" && !target.namespaced {
+			continue
+		}
+		wg.Add(1)
+		go func(order int, target searchKind) {
+			defer wg.Done()
+			group, err := s.searchKind(ctx, target, keyword, namespace, limit)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				searchResp.Errors[target.gvr.Resource] = err.Error()
+				return
+			}
+			if group.Total == 0 {
+				return
+			}
+			group.order = order
+			searchResp.Total += group.Total
+			searchResp.Groups = append(searchResp.Groups, group)
+		}(i, target)
+	}
+	wg.Wait()
+
+	// 分组按最高得分排序，得分相同时按searchKinds中的顺序
+	sort.Slice(searchResp.Groups, func(i, j int) bool {
+		if searchResp.Groups[i].score != searchResp.Groups[j].score {
+			return searchResp.Groups[i].score > searchResp.Groups[j].score
+		}
+		return searchResp.Groups[i].order < searchResp.Groups[j].order
+	})
+	return searchResp, nil
+}
+
+// kinds 根据请求中的资源名称获取需要搜索的资源类型
+func (s *search) kinds(resources []string) ([]searchKind, error) {
+	if len(resources) == 0 {
+		return searchKinds, nil
+	}
+	var targets []searchKind
+	for _, resource := range resources {
+		resource = strings.ToLower(strings.TrimSpace(resource))
+		if resource == "" {
+			continue
+		}
+		found := false
+		for _, kind := range searchKinds {
+			if kind.gvr.Resource == resource {
+				targets = append(targets, kind)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("不支持搜索的资源类型 " + resource)
+		}
+	}
+	return targets, nil
+}
+
+// searchKind 搜索单个资源类型，结果按得分和名称排序并截断为limit条
+func (s *search) searchKind(ctx context.Context, target searchKind, keyword, namespace string, limit int) (*SearchGroup, error) {
+	client := K8s.MetadataClient.Resource(target.gvr)
+	var list *metav1.PartialObjectMetadataList
+	var err error
+	if target.namespaced {
+		list, err = client.Namespace(namespace).List(ctx, metav1.ListOptions{})
+	} else {
+		list, err = client.List(ctx, metav1.ListOptions{})
+	}
+	if err != nil {
+		logger.Error(errors.New("搜索" + target.kind + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("搜索" + target.kind + "失败,错误信息 " + err.Error())
+	}
+
+	group := &SearchGroup{Kind: target.kind, Resource: target.gvr.Resource}
+	for i := range list.Items {
+		item := &list.Items[i]
+		score, matchedBy := s.match(&item.ObjectMeta, keyword)
+		if score == 0 {
+			continue
+		}
+		group.Items = append(group.Items, &SearchResult{
+			Kind:              target.kind,
+			Name:              item.Name,
+			Namespace:         item.Namespace,
+			Labels:            item.Labels,
+			MatchedBy:         matchedBy,
+			Score:             score,
+			CreationTimestamp: item.CreationTimestamp.Time,
+			Link:              s.link(target, item.Name, item.Namespace),
+		})
+	}
+	sort.Slice(group.Items, func(i, j int) bool {
+		if group.Items[i].Score != group.Items[j].Score {
+			return group.Items[i].Score > group.Items[j].Score
+		}
+		return group.Items[i].Name < group.Items[j].Name
+	})
+	group.Total = len(group.Items)
+	if group.Total > limit {
+		group.Items = group.Items[:limit]
+	}
+	if group.Total > 0 {
+		group.score = group.Items[0].Score
+	}
+	return group, nil
+}
+
+// match 计算资源与关键字的匹配得分，名称优先于标签，标签优先于注解
+// 标签和注解按key或key=value匹配，last-applied-configuration注解内容过长，不参与匹配
+func (s *search) match(objectMeta *metav1.ObjectMeta, keyword string) (score int, matchedBy string) {
+	name := strings.ToLower(objectMeta.Name)
+	switch {
+	case name == keyword:
+		return scoreExactName, "name"
+	case strings.HasPrefix(name, keyword):
+		return scoreNamePrefix, "name"
+	case strings.Contains(name, keyword):
+		return scoreNameContain, "name"
+	}
+	for key, value := range objectMeta.Labels {
+		if strings.Contains(strings.ToLower(key+"="+value), keyword) {
+			return scoreLabel, "label"
+		}
+	}
+	for key, value := range objectMeta.Annotations {
+		if key == "kubectl.kubernetes.io/last-applied-configuration" {
+			continue
+		}
+		if strings.Contains(strings.ToLower(key+"="+value), keyword) {
+			return scoreAnnotation, "annotation"
+		}
+	}
+	return 0, ""
+}
+
+// link 生成资源详情接口的链接
+func (s *search) link(target searchKind, name, namespace string) string {
+	values := url.Values{}
+	values.Set(target.nameParam, name)
+	if target.namespaced {
+		values.Set("namespace", namespace)
+	}
+	return target.detail + "?" + values.Encode()
+}
@@ -16,12 +16,19 @@ type secret struct{}
 type SecretsResp struct {
 	Items []corev1.Secret `json:"items"`
 	Total int             `json:"total"`
+	ListMeta
 }
 
 // GetSecrets 获取secret列表，支持过滤、排序和分页
-func (s *secret) GetSecrets(filterName, namespace string, limit, page int) (secretsResp *SecretsResp, err error) {
+func (s *secret) GetSecrets(filterName, namespace string, limit, page int, selectParams *SelectParams) (secretsResp *SecretsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取secretList类型的secret列表
-	secretList, err := K8s.ClientSet.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{})
+	secretList, err := K8s.ClientSet.CoreV1().Secrets(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取Secret列表失败,错误信息, " + err.Error()))
 		return nil, errors.New("获取Secret列表失败,错误信息, " + err.Error())
@@ -29,23 +36,18 @@ func (s *secret) GetSecrets(filterName, namespace string, limit, page int) (secr
 	// 将secretList中的secret列表(Items),放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: s.toCells(secretList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{filterName},
-			Paginate: &PaginateQuery{
-				Limit: limit,
-				Page:  page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(secretList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的secret列表转为v1.secret列表
 	secrets := s.fromCells(data.GenericDataList)
 	return &SecretsResp{
-		Items: secrets,
-		Total: total,
+		Items:    secrets,
+		Total:    total,
+		ListMeta: newListMeta(secretList.ListMeta),
 	}, nil
 }
 
@@ -79,12 +81,30 @@ func (s *secret) UpdateSecret(namespace, content string) (err error) {
 	}
 	_, err = K8s.ClientSet.CoreV1().Secrets(namespace).Update(context.TODO(), secret, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "Secret", corev1.SchemeGroupVersion.WithResource("secrets"), namespace, secret); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新Secret失败,错误信息 " + err.Error()))
 		return errors.New("更新Secret失败,错误信息 " + err.Error())
 	}
 	return nil
 }
 
+// PatchSecret 对Secret打补丁，patchType支持json、merge、strategic，默认为merge
+func (s *secret) PatchSecret(secretName, namespace, patchType, content string) (secret *corev1.Secret, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	secret, err = K8s.ClientSet.CoreV1().Secrets(namespace).Patch(context.TODO(), secretName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改Secret " + secretName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改Secret " + secretName + "失败,错误信息 " + err.Error())
+	}
+	return secret, nil
+}
+
 func (s *secret) toCells(std []corev1.Secret) []DataCell {
 	cells := make([]DataCell, len(std))
 	for i := range std {
@@ -20,6 +20,7 @@ type servicev1 struct{}
 type ServicesResp struct {
 	Items []corev1.Service `json:"items"`
 	Total int              `json:"total"`
+	ListMeta
 }
 
 // ServiceCreate 定义service创建结构体对象
@@ -35,9 +36,15 @@ type ServiceCreate struct {
 }
 
 // GetServices 获取service列表、支持过滤、排序和分页
-func (s *servicev1) GetServices(filterName, namespace string, limit, page int) (servicesResp *ServicesResp, err error) {
+func (s *servicev1) GetServices(filterName, namespace string, limit, page int, selectParams *SelectParams) (servicesResp *ServicesResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取serviceList类型的service列表
-	serviceList, err := K8s.ClientSet.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	serviceList, err := K8s.ClientSet.CoreV1().Services(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取Service列表失败,错误信息, " + err.Error()))
 		return nil, errors.New("获取Service列表失败,错误信息, " + err.Error())
@@ -45,25 +52,18 @@ func (s *servicev1) GetServices(filterName, namespace string, limit, page int) (
 	// 将serviceList中的service列表(Items),放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: s.toCells(serviceList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{
-				Name: filterName,
-			},
-			Paginate: &PaginateQuery{
-				Limit: limit,
-				Page:  page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(serviceList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将将[]DataCell类型的service列表转为v1.service列表
 	services := s.fromCells(data.GenericDataList)
 	return &ServicesResp{
-		Items: services,
-		Total: total,
+		Items:    services,
+		Total:    total,
+		ListMeta: newListMeta(serviceList.ListMeta),
 	}, nil
 }
 
@@ -137,12 +137,30 @@ func (s *servicev1) UpdateService(namespace, content string) (err error) {
 	}
 	_, err = K8s.ClientSet.CoreV1().Services(namespace).Update(context.TODO(), service, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "Service", corev1.SchemeGroupVersion.WithResource("services"), namespace, service); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新service失败, " + err.Error()))
 		return errors.New("更新service失败, " + err.Error())
 	}
 	return nil
 }
 
+// PatchService 对service打补丁，patchType支持json、merge、strategic，默认为merge
+func (s *servicev1) PatchService(serviceName, namespace, patchType, content string) (service *corev1.Service, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	service, err = K8s.ClientSet.CoreV1().Services(namespace).Patch(context.TODO(), serviceName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改service " + serviceName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改service " + serviceName + "失败,错误信息 " + err.Error())
+	}
+	return service, nil
+}
+
 func (s *servicev1) toCells(std []corev1.Service) []DataCell {
 	cells := make([]DataCell, len(std))
 	for i := range std {
@@ -6,7 +6,12 @@ import (
 	"errors"
 	"github.com/wonderivan/logger"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // StatefulSet 实例化statefulSet结构体
@@ -19,12 +24,49 @@ type statefulSet struct{}
 type StatefulSetsResp struct {
 	Items []appsv1.StatefulSet `json:"items"`
 	Total int                  `json:"total"`
+	ListMeta
+}
+
+// StatefulSetCreate 定义StatefulSetCreate结构体，用于创建statefulSet需要的参数属性的定义
+// ServiceName为管理pod网络标识的headless service名称，为空时使用Name，CreateService为true且service不存在时自动创建
+// PodManagementPolicy可选值为OrderedReady、Parallel，默认为OrderedReady
+type StatefulSetCreate struct {
+	Name                 string                `json:"name"`
+	Namespace            string                `json:"namespace"`
+	Replicas             int32                 `json:"replicas"`
+	Image                string                `json:"image"`
+	Label                map[string]string     `json:"label"`
+	Cpu                  string                `json:"cpu"`
+	Memory               string                `json:"memory"`
+	ContainerPort        int32                 `json:"containerPort"`
+	HealthCheck          bool                  `json:"healthCheck"`
+	HealthPath           string                `json:"healthPath"`
+	ServiceName          string                `json:"serviceName"`
+	CreateService        bool                  `json:"createService"`
+	PodManagementPolicy  string                `json:"podManagementPolicy"`
+	VolumeClaimTemplates []VolumeClaimTemplate `json:"volumeClaimTemplates"`
+}
+
+// VolumeClaimTemplate 定义statefulSet的卷声明模板，每个pod会创建一个独立的pvc并挂载到MountPath
+// AccessModes默认为ReadWriteOnce，StorageClassName为空时使用集群默认的StorageClass
+type VolumeClaimTemplate struct {
+	Name             string   `json:"name"`
+	StorageClassName string   `json:"storageClassName"`
+	AccessModes      []string `json:"accessModes"`
+	Storage          string   `json:"storage"`
+	MountPath        string   `json:"mountPath"`
 }
 
 // GetStatefulSets 获取statefulSets列表、支持过滤、排序、分页
-func (s *statefulSet) GetStatefulSets(filterName, namespace string, limit, page int) (statefulSetsResp *StatefulSetsResp, err error) {
+func (s *statefulSet) GetStatefulSets(filterName, namespace string, limit, page int, selectParams *SelectParams) (statefulSetsResp *StatefulSetsResp, err error) {
+	// 组装过滤、排序、分页条件，参数不合法时直接返回
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 	// 获取statefulSetList类型的statefulSet
-	statefulSetList, err := K8s.ClientSet.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	statefulSetList, err := K8s.ClientSet.AppsV1().StatefulSets(namespace).List(context.TODO(), dataSelectQuery.ListOptions())
 	if err != nil {
 		logger.Error(errors.New("获取statefulSet列表失败，错误信息" + err.Error()))
 		return nil, errors.New("获取statefulSet列表失败，错误信息" + err.Error())
@@ -32,24 +74,19 @@ func (s *statefulSet) GetStatefulSets(filterName, namespace string, limit, page
 	// 将statefulSetList中的StatefulSet列表(Items)，放进dataselector对象中，进行排序
 	selectableData := &dataSelector{
 		GenericDataList: s.toCells(statefulSetList.Items),
-		DataSelectQuery: &DataSelectQuery{
-			Filter: &FilterQuery{Name: filterName},
-			Paginate: &PaginateQuery{
-				limit,
-				page,
-			},
-		},
+		DataSelectQuery: dataSelectQuery,
 	}
 	filtered := selectableData.Filter()
-	total := len(filtered.GenericDataList)
+	total := filtered.Total(statefulSetList.RemainingItemCount)
 	data := filtered.Sort().Paginate()
 
 	// 将[]DataCell类型的statefulSet列表转为v1.statefulset列表
 	statefulSets := s.fromCells(data.GenericDataList)
 
 	return &StatefulSetsResp{
-		Items: statefulSets,
-		Total: total,
+		Items:    statefulSets,
+		Total:    total,
+		ListMeta: newListMeta(statefulSetList.ListMeta),
 	}, nil
 }
 
@@ -63,6 +100,163 @@ func (s *statefulSet) GetStatefulSetDetail(statefulSetName, namespace string) (s
 	return statefulSet, nil
 }
 
+// ScaleStatefulSet 设置statefulSet副本数
+func (s *statefulSet) ScaleStatefulSet(statefulSetName, namespace string, scaleNum int) (replica int32, err error) {
+	// 获取autoscalingv1.Scale类型的对象，能点出当前的副本数
+	scale, err := K8s.ClientSet.AppsV1().StatefulSets(namespace).GetScale(context.TODO(), statefulSetName, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取statefulSet " + statefulSetName + "副本数信息失败,错误信息 " + err.Error()))
+		return 0, errors.New("获取statefulSet " + statefulSetName + "副本数信息失败,错误信息 " + err.Error())
+	}
+	scale.Spec.Replicas = int32(scaleNum)
+	newScale, err := K8s.ClientSet.AppsV1().StatefulSets(namespace).UpdateScale(context.TODO(), statefulSetName, scale, metav1.UpdateOptions{})
+	if err != nil {
+		logger.Error(errors.New("更新statefulSet " + statefulSetName + "副本数失败,错误信息 " + err.Error()))
+		return 0, errors.New("更新statefulSet " + statefulSetName + "副本数失败,错误信息 " + err.Error())
+	}
+	return newScale.Spec.Replicas, nil
+}
+
+// CreateStatefulSet 创建statefulSet，接受StatefulSetCreate对象
+func (s *statefulSet) CreateStatefulSet(data *StatefulSetCreate) (err error) {
+	if data.ServiceName == "" {
+		data.ServiceName = data.Name
+	}
+	policy := appsv1.PodManagementPolicyType(data.PodManagementPolicy)
+	switch policy {
+	case "":
+		policy = appsv1.OrderedReadyPodManagement
+	case appsv1.OrderedReadyPodManagement, appsv1.ParallelPodManagement:
+	default:
+		logger.Error(errors.New("不支持的podManagementPolicy " + data.PodManagementPolicy + ",可选值为OrderedReady、Parallel"))
+		return errors.New("不支持的podManagementPolicy " + data.PodManagementPolicy + ",可选值为OrderedReady、Parallel")
+	}
+	container, err := newContainer(data.Name, data.Image, data.ContainerPort, data.Cpu, data.Memory, data.HealthCheck, data.HealthPath)
+	if err != nil {
+		return err
+	}
+	claims, mounts, err := newVolumeClaimTemplates(data.VolumeClaimTemplates)
+	if err != nil {
+		return err
+	}
+	container.VolumeMounts = mounts
+
+	// 将data中的数据组装成appsv1.StatefulSet对象
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      data.Name,
+			Namespace: data.Namespace,
+			Labels:    data.Label,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &data.Replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: data.Label,
+			},
+			ServiceName:         data.ServiceName,
+			PodManagementPolicy: policy,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: data.Label,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+				},
+			},
+			VolumeClaimTemplates: claims,
+		},
+	}
+	_, err = K8s.ClientSet.AppsV1().StatefulSets(data.Namespace).Create(context.TODO(), statefulSet, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("创建statefulSet " + data.Name + "失败,错误信息 " + err.Error()))
+		return errors.New("创建statefulSet " + data.Name + "失败,错误信息 " + err.Error())
+	}
+	// 先创建statefulSet再创建service，避免statefulSet创建失败时遗留无用的service
+	// statefulSet不要求service先于自身存在，service创建后pod的dns记录即可生效
+	if data.CreateService {
+		if err := s.createHeadlessService(data); err != nil {
+			return errors.New("statefulSet " + data.Name + "已创建," + err.Error())
+		}
+	}
+	return nil
+}
+
+// createHeadlessService 创建statefulSet使用的headless service，service已存在时跳过
+func (s *statefulSet) createHeadlessService(data *StatefulSetCreate) error {
+	_, err := K8s.ClientSet.CoreV1().Services(data.Namespace).Get(context.TODO(), data.ServiceName, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		logger.Error(errors.New("获取service " + data.ServiceName + "失败,错误信息 " + err.Error()))
+		return errors.New("获取service " + data.ServiceName + "失败,错误信息 " + err.Error())
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      data.ServiceName,
+			Namespace: data.Namespace,
+			Labels:    data.Label,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  data.Label,
+		},
+	}
+	if data.ContainerPort > 0 {
+		service.Spec.Ports = []corev1.ServicePort{{
+			Name:       "http",
+			Port:       data.ContainerPort,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(int(data.ContainerPort)),
+		}}
+	}
+	_, err = K8s.ClientSet.CoreV1().Services(data.Namespace).Create(context.TODO(), service, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error(errors.New("创建headless service " + data.ServiceName + "失败,错误信息 " + err.Error()))
+		return errors.New("创建headless service " + data.ServiceName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
+// newVolumeClaimTemplates 根据表单参数组装卷声明模板及对应的挂载点
+func newVolumeClaimTemplates(templates []VolumeClaimTemplate) ([]corev1.PersistentVolumeClaim, []corev1.VolumeMount, error) {
+	claims := make([]corev1.PersistentVolumeClaim, 0, len(templates))
+	mounts := make([]corev1.VolumeMount, 0, len(templates))
+	for _, template := range templates {
+		if template.Name == "" || template.MountPath == "" {
+			logger.Error(errors.New("卷声明模板的name和mountPath不能为空"))
+			return nil, nil, errors.New("卷声明模板的name和mountPath不能为空")
+		}
+		storage, err := resource.ParseQuantity(template.Storage)
+		if err != nil {
+			logger.Error(errors.New("卷声明模板 " + template.Name + "的容量 " + template.Storage + "格式错误,错误信息 " + err.Error()))
+			return nil, nil, errors.New("卷声明模板 " + template.Name + "的容量 " + template.Storage + "格式错误,错误信息 " + err.Error())
+		}
+		accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+		if len(template.AccessModes) > 0 {
+			accessModes = accessModes[:0]
+			for _, mode := range template.AccessModes {
+				accessModes = append(accessModes, corev1.PersistentVolumeAccessMode(mode))
+			}
+		}
+		claim := corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: template.Name},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: accessModes,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: storage},
+				},
+			},
+		}
+		if template.StorageClassName != "" {
+			claim.Spec.StorageClassName = &template.StorageClassName
+		}
+		claims = append(claims, claim)
+		mounts = append(mounts, corev1.VolumeMount{Name: template.Name, MountPath: template.MountPath})
+	}
+	return claims, mounts, nil
+}
+
 // DeleteStatefulSet 删除statefulSet
 func (s *statefulSet) DeleteStatefulSet(statefulSetName, namespace string) (err error) {
 	err = K8s.ClientSet.AppsV1().StatefulSets(namespace).Delete(context.TODO(), statefulSetName, metav1.DeleteOptions{})
@@ -74,6 +268,23 @@ func (s *statefulSet) DeleteStatefulSet(statefulSetName, namespace string) (err
 	return nil
 }
 
+// RestartStatefulSet 重启statefulset，与kubectl rollout restart一致，修改pod模板的restartedAt注解触发滚动更新，暂停中的statefulset需要先恢复
+func (s *statefulSet) RestartStatefulSet(statefulSetName, namespace string) (err error) {
+	if err := Rollout.checkRestartable("statefulset", statefulSetName, namespace); err != nil {
+		return err
+	}
+	patchByte, err := restartPatch()
+	if err != nil {
+		return err
+	}
+	_, err = K8s.ClientSet.AppsV1().StatefulSets(namespace).Patch(context.TODO(), statefulSetName, types.StrategicMergePatchType, patchByte, metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("重启statefulset " + statefulSetName + "失败,错误信息 " + err.Error()))
+		return errors.New("重启statefulset " + statefulSetName + "失败,错误信息 " + err.Error())
+	}
+	return nil
+}
+
 // UpdateStatefulSet 更新statefulSet
 func (s *statefulSet) UpdateStatefulSet(namespace, content string) (err error) {
 	var statefulSet = &appsv1.StatefulSet{}
@@ -86,12 +297,30 @@ func (s *statefulSet) UpdateStatefulSet(namespace, content string) (err error) {
 
 	_, err = K8s.ClientSet.AppsV1().StatefulSets(namespace).Update(context.TODO(), statefulSet, metav1.UpdateOptions{})
 	if err != nil {
+		if conflict := conflictError(err, "StatefulSet", appsv1.SchemeGroupVersion.WithResource("statefulsets"), namespace, statefulSet); conflict != nil {
+			return conflict
+		}
 		logger.Error(errors.New("更新StatefulSet失败, " + err.Error()))
 		return errors.New("更新StatefulSet失败, " + err.Error())
 	}
 	return nil
 }
 
+// PatchStatefulSet 对StatefulSet打补丁，patchType支持json、merge、strategic，默认为merge
+func (s *statefulSet) PatchStatefulSet(statefulSetName, namespace, patchType, content string) (statefulSet *appsv1.StatefulSet, err error) {
+	pt, err := parsePatchType(patchType)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	statefulSet, err = K8s.ClientSet.AppsV1().StatefulSets(namespace).Patch(context.TODO(), statefulSetName, pt, []byte(content), metav1.PatchOptions{})
+	if err != nil {
+		logger.Error(errors.New("修改StatefulSet " + statefulSetName + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("修改StatefulSet " + statefulSetName + "失败,错误信息 " + err.Error())
+	}
+	return statefulSet, nil
+}
+
 func (s *statefulSet) toCells(std []appsv1.StatefulSet) []DataCell {
 	cells := make([]DataCell, len(std))
 	for i := range std {
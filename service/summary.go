@@ -0,0 +1,221 @@
+package service
+
+import (
+	"errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"time"
+)
+
+// 列表接口的精简视图，只返回列表页需要展示的字段，不包含managedFields等完整对象内容
+// 通过view=summary参数选择，详情接口仍返回完整对象
+
+// 列表接口支持的视图
+const (
+	ViewFull    = "full"
+	ViewSummary = "summary"
+)
+
+// CheckView 校验列表接口的view参数，为空时等同于full
+func CheckView(view string) error {
+	switch view {
+	case "", ViewFull, ViewSummary:
+		return nil
+	}
+	return errors.New("不支持的view参数 " + view + ",可选值为full、summary")
+}
+
+// PodSummary 定义pod列表的精简视图
+type PodSummary struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Labels            map[string]string `json:"labels"`
+	Phase             string            `json:"phase"`
+	Status            string            `json:"status"`
+	ReadyContainers   int               `json:"readyContainers"`
+	TotalContainers   int               `json:"totalContainers"`
+	Restarts          int32             `json:"restarts"`
+	Node              string            `json:"node"`
+	IP                string            `json:"ip"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	Age               string            `json:"age"`
+}
+
+// PodSummariesResp 定义pod精简列表的返回内容
+type PodSummariesResp struct {
+	Items []*PodSummary `json:"items"`
+	Total int           `json:"total"`
+	ListMeta
+}
+
+// DeploymentSummary 定义deployment列表的精简视图
+type DeploymentSummary struct {
+	Name              string             `json:"name"`
+	Namespace         string             `json:"namespace"`
+	Labels            map[string]string  `json:"labels"`
+	DesiredReplicas   int32              `json:"desiredReplicas"`
+	ReadyReplicas     int32              `json:"readyReplicas"`
+	UpdatedReplicas   int32              `json:"updatedReplicas"`
+	AvailableReplicas int32              `json:"availableReplicas"`
+	Images            []string           `json:"images"`
+	Conditions        []ConditionSummary `json:"conditions"`
+	CreationTimestamp time.Time          `json:"creationTimestamp"`
+	Age               string             `json:"age"`
+}
+
+// ConditionSummary 定义资源状态条件的精简视图
+type ConditionSummary struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// DeploymentSummariesResp 定义deployment精简列表的返回内容
+type DeploymentSummariesResp struct {
+	Items []*DeploymentSummary `json:"items"`
+	Total int                  `json:"total"`
+	ListMeta
+}
+
+// GetPodSummaries 获取pod精简列表，过滤、排序、分页与GetPods一致
+func (p *pod) GetPodSummaries(filterName, namespace string, limit, page int, selectParams *SelectParams) (summariesResp *PodSummariesResp, err error) {
+	podsResp, err := p.GetPods(filterName, namespace, limit, page, selectParams)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*PodSummary, len(podsResp.Items))
+	for i := range podsResp.Items {
+		items[i] = podSummary(&podsResp.Items[i])
+	}
+	return &PodSummariesResp{
+		Items:    items,
+		Total:    podsResp.Total,
+		ListMeta: podsResp.ListMeta,
+	}, nil
+}
+
+// GetDeploymentSummaries 获取deployment精简列表，过滤、排序、分页与GetDeployments一致
+func (d *deployment) GetDeploymentSummaries(filterName, namespace string, limit, page int, selectParams *SelectParams) (summariesResp *DeploymentSummariesResp, err error) {
+	deploymentsResp, err := d.GetDeployments(filterName, namespace, limit, page, selectParams)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*DeploymentSummary, len(deploymentsResp.Items))
+	for i := range deploymentsResp.Items {
+		items[i] = deploymentSummary(&deploymentsResp.Items[i])
+	}
+	return &DeploymentSummariesResp{
+		Items:    items,
+		Total:    deploymentsResp.Total,
+		ListMeta: deploymentsResp.ListMeta,
+	}, nil
+}
+
+// podSummary 将pod转换为精简视图
+func podSummary(pod *corev1.Pod) *PodSummary {
+	ready := 0
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Ready {
+			ready++
+		}
+	}
+	return &PodSummary{
+		Name:              pod.Name,
+		Namespace:         pod.Namespace,
+		Labels:            pod.Labels,
+		Phase:             string(pod.Status.Phase),
+		Status:            podStatus(pod),
+		ReadyContainers:   ready,
+		TotalContainers:   len(pod.Spec.Containers),
+		Restarts:          podRestarts(pod.Status),
+		Node:              pod.Spec.NodeName,
+		IP:                pod.Status.PodIP,
+		CreationTimestamp: pod.CreationTimestamp.Time,
+		Age:               age(pod.CreationTimestamp.Time),
+	}
+}
+
+// podStatus 获取pod的展示状态，与kubectl get pods的STATUS列一致，
+// 容器处于等待或异常退出时返回原因，如CrashLoopBackOff、ImagePullBackOff、OOMKilled
+func podStatus(pod *corev1.Pod) string {
+	if pod.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	status := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		status = pod.Status.Reason
+	}
+	for _, containerStatus := range pod.Status.InitContainerStatuses {
+		if containerStatus.State.Terminated != nil && containerStatus.State.Terminated.ExitCode == 0 {
+			continue
+		}
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" && containerStatus.State.Waiting.Reason != "PodInitializing" {
+			return "Init:" + containerStatus.State.Waiting.Reason
+		}
+		if containerStatus.State.Terminated != nil && containerStatus.State.Terminated.Reason != "" {
+			return "Init:" + containerStatus.State.Terminated.Reason
+		}
+		return "Init"
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" {
+			status = containerStatus.State.Waiting.Reason
+		} else if containerStatus.State.Terminated != nil && containerStatus.State.Terminated.Reason != "" {
+			status = containerStatus.State.Terminated.Reason
+		}
+	}
+	return status
+}
+
+// deploymentSummary 将deployment转换为精简视图
+func deploymentSummary(deployment *appsv1.Deployment) *DeploymentSummary {
+	var desired int32 = 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	images := make([]string, 0, len(deployment.Spec.Template.Spec.Containers))
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	conditions := make([]ConditionSummary, 0, len(deployment.Status.Conditions))
+	for _, condition := range deployment.Status.Conditions {
+		conditions = append(conditions, ConditionSummary{
+			Type:    string(condition.Type),
+			Status:  string(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+	return &DeploymentSummary{
+		Name:              deployment.Name,
+		Namespace:         deployment.Namespace,
+		Labels:            deployment.Labels,
+		DesiredReplicas:   desired,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		Images:            images,
+		Conditions:        conditions,
+		CreationTimestamp: deployment.CreationTimestamp.Time,
+		Age:               age(deployment.CreationTimestamp.Time),
+	}
+}
+
+// age 计算资源的存在时长，格式与kubectl的AGE列一致，如5m、3d4h
+func age(creation time.Time) string {
+	if creation.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(creation))
+}
+
+// dateToAge 将printer column中type为date的RFC3339时间转换为age，与kubectl get一致，无法解析的值保持原样
+func dateToAge(value string) string {
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return age(timestamp)
+}
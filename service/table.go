@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/wonderivan/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strconv"
+)
+
+// server-side Table输出，请求apiserver以Table格式返回列表，列定义与kubectl get一致，
+// 内置资源和CRD(包括additionalPrinterColumns)均由apiserver负责生成列，date类型的列转换为age后返回
+
+// ViewTable 通用资源列表接口使用server-side Table输出
+const ViewTable = "table"
+
+// tableAccept 请求Table格式的Accept头，apiserver不支持时回退为普通json
+const tableAccept = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// ResourceTableResp 定义Table格式列表的返回内容，Rows中每行的Cells与Columns一一对应，
+// Object为该行资源的metadata
+type ResourceTableResp struct {
+	Columns []metav1.TableColumnDefinition `json:"columns"`
+	Rows    []metav1.TableRow              `json:"rows"`
+	Total   int                            `json:"total"`
+	ListMeta
+}
+
+// GetResourceTable 以Table格式获取任意资源的列表，支持过滤、排序、分页
+// 过滤中的field可以使用Table的列名，如status=Running
+func (r *dynamicResource) GetResourceTable(resourceType *ResourceType, filterName, namespace string, limit, page int, selectParams *SelectParams) (tableResp *ResourceTableResp, err error) {
+	dataSelectQuery, err := newDataSelectQuery(filterName, limit, page, selectParams)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	gvr, namespaced, err := r.resolve(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	if !namespaced {
+		namespace = ""
+	}
+	options := dataSelectQuery.ListOptions()
+	request := K8s.ClientSet.CoreV1().RESTClient().Get().
+		AbsPath(resourcePath(gvr, namespace)...).
+		SetHeader("Accept", tableAccept).
+		Param("includeObject", string(metav1.IncludeMetadata))
+	if options.Limit > 0 {
+		request = request.Param("limit", strconv.FormatInt(options.Limit, 10))
+	}
+	if options.Continue != "" {
+		request = request.Param("continue", options.Continue)
+	}
+	if options.LabelSelector != "" {
+		request = request.Param("labelSelector", options.LabelSelector)
+	}
+	data, err := request.Do(context.TODO()).Raw()
+	if err != nil {
+		logger.Error(errors.New("获取" + gvr.String() + "表格失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取" + gvr.String() + "表格失败,错误信息 " + err.Error())
+	}
+	table := &metav1.Table{}
+	if err := json.Unmarshal(data, table); err != nil || table.Kind != "Table" {
+		logger.Error(errors.New("资源类型 " + gvr.String() + "不支持Table格式输出"))
+		return nil, errors.New("资源类型 " + gvr.String() + "不支持Table格式输出")
+	}
+
+	cells, err := r.tableToCells(table)
+	if err != nil {
+		return nil, err
+	}
+	selectableData := &dataSelector{
+		GenericDataList: cells,
+		DataSelectQuery: dataSelectQuery,
+	}
+	filtered := selectableData.Filter()
+	total := filtered.Total(table.RemainingItemCount)
+	result := filtered.Sort().Paginate()
+
+	rows := make([]metav1.TableRow, len(result.GenericDataList))
+	for i, cell := range result.GenericDataList {
+		rows[i] = cell.(tableRowCell).row
+	}
+	return &ResourceTableResp{
+		Columns:  table.ColumnDefinitions,
+		Rows:     rows,
+		Total:    total,
+		ListMeta: newListMeta(table.ListMeta),
+	}, nil
+}
+
+// tableToCells 将Table的行转换为DataCell，解析每行的metadata并去掉managedFields
+func (r *dynamicResource) tableToCells(table *metav1.Table) ([]DataCell, error) {
+	cells := make([]DataCell, len(table.Rows))
+	for i, row := range table.Rows {
+		meta := &metav1.PartialObjectMetadata{}
+		if len(row.Object.Raw) > 0 {
+			if err := json.Unmarshal(row.Object.Raw, meta); err != nil {
+				logger.Error(errors.New("解析表格行metadata失败,错误信息 " + err.Error()))
+				return nil, errors.New("解析表格行metadata失败,错误信息 " + err.Error())
+			}
+		}
+		meta.ManagedFields = nil
+		raw, err := json.Marshal(meta)
+		if err != nil {
+			logger.Error(errors.New("序列化表格行metadata失败,错误信息 " + err.Error()))
+			return nil, errors.New("序列化表格行metadata失败,错误信息 " + err.Error())
+		}
+		row.Object = runtime.RawExtension{Raw: raw}
+		dateCellsToAge(row.Cells, table.ColumnDefinitions)
+		cells[i] = tableRowCell{row: row, meta: meta, columns: table.ColumnDefinitions}
+	}
+	return cells, nil
+}
+
+// dateCellsToAge 将type为date的列由RFC3339时间转换为与kubectl get一致的age，如5m、3d
+// 资源的创建时间仍可以从行的metadata中获取，无法解析的值保持原样
+func dateCellsToAge(cells []interface{}, columns []metav1.TableColumnDefinition) {
+	for i, column := range columns {
+		if column.Type != "date" || i >= len(cells) {
+			continue
+		}
+		if value, ok := cells[i].(string); ok && value != "" {
+			cells[i] = dateToAge(value)
+		}
+	}
+}
+
+// resourcePath 组装资源列表的请求路径
+// 如 /api/v1/namespaces/default/pods、/apis/apps/v1/deployments
+func resourcePath(gvr schema.GroupVersionResource, namespace string) []string {
+	segments := []string{"/api", gvr.Version}
+	if gvr.Group != "" {
+		segments = []string{"/apis", gvr.Group, gvr.Version}
+	}
+	if namespace != "" {
+		segments = append(segments, "namespaces", namespace)
+	}
+	return append(segments, gvr.Resource)
+}
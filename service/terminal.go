@@ -7,11 +7,17 @@ import (
 	"fmt"
 	"github.com/gorilla/websocket"
 	"github.com/wonderivan/logger"
+	"io"
 	v1 "k8s.io/api/core/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 )
 
 // TerminalMessage定义了终端和容器shell交互内容的格式 //Operation是操作类型
@@ -34,11 +40,44 @@ var upgrader = func() websocket.Upgrader {
 	return upgrader
 }()
 
+// validShells 终端允许执行的shell
+var validShells = map[string]bool{
+	"/bin/bash": true,
+	"/bin/sh":   true,
+	"bash":      true,
+	"sh":        true,
+}
+
 // TerminalSession 定义TerminalSession结构体，实现PtyHandler接口 //wsConn是websocket连接 //sizeChan用来定义终端输入和输出的宽和高 //doneChan用于标记退出终端
+// websocket断开后会话不会立即结束，exec stream在宽限期内保持运行，客户端可以凭会话id重新连接并收到断开期间的输出
 type TerminalSession struct {
 	wsConn   *websocket.Conn
 	sizeChan chan remotecommand.TerminalSize
 	doneChan chan struct{}
+	// inputChan用于将websocket读到的标准输入传递给exec stream，pending为上次未读完的输入
+	inputChan chan []byte
+	pending   []byte
+	// 会话元数据，用于会话管理
+	id            string
+	username      string
+	namespace     string
+	podName       string
+	containerName string
+	remoteAddr    string
+	startTime     time.Time
+	lastActivity  int64 // unix纳秒时间戳，原子读写
+	// lock保护wsConn、detachedAt、scrollback以及共享相关字段，同时保证websocket同一时间只有一个写入者
+	lock       sync.Mutex
+	detachedAt time.Time
+	scrollback []byte
+	// 只读共享，shareToken为空时表示未共享，lastSize用于新加入的观察者同步终端大小
+	shareToken string
+	viewers    map[string]*terminalViewer
+	revoked    map[string]bool
+	lastSize   *remotecommand.TerminalSize
+	// doneOnce和closeOnce保证重复关闭是安全的
+	doneOnce  sync.Once
+	closeOnce sync.Once
 }
 
 // Terminal 定义Terminal全局变量
@@ -48,6 +87,7 @@ var Terminal terminal
 type terminal struct{}
 
 // WsHandler 定义websocket的handler方法
+// 携带sessionId参数时，重新连接到已存在的会话
 func (t *terminal) WsHandler(w http.ResponseWriter, r *http.Request) {
 	// 加载k8s配置
 	conf, err := clientcmd.BuildConfigFromFlags("", config.Kubeconfig)
@@ -60,10 +100,38 @@ func (t *terminal) WsHandler(w http.ResponseWriter, r *http.Request) {
 		logger.Error("解析参数失败,错误信息," + err.Error())
 		return
 	}
+	// 识别当前用户，未携带token时记为anonymous，携带了非法token则拒绝
+	username := "anonymous"
+	if r.Header.Get("Authorization") != "" || r.Form.Get("token") != "" {
+		username, err = getRequestUser(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	// 重新连接已存在的会话，mode=view时以只读观察者身份加入共享会话
+	if sessionId := r.Form.Get("sessionId"); sessionId != "" {
+		if r.Form.Get("mode") == "view" {
+			t.watch(w, r, sessionId, username, r.Form.Get("shareToken"))
+			return
+		}
+		t.reattach(w, r, sessionId, username)
+		return
+	}
 	// 如果解析成功
 	namespace := r.Form.Get("namespace")
 	podName := r.Form.Get("podName")
 	containerName := r.Form.Get("containerName")
+	// shell为可选参数，distroless等镜像的调试容器(如busybox)通常只有/bin/sh
+	shell := r.Form.Get("shell")
+	if shell == "" {
+		shell = config.TerminalShell
+	}
+	if !validShells[shell] {
+		logger.Error("不支持的shell类型," + shell)
+		http.Error(w, "不支持的shell类型 "+shell, http.StatusBadRequest)
+		return
+	}
 	logger.Info("exec pod: %s, container: %s, namespace: %s\n", podName, containerName, namespace)
 
 	// new一个TerminalSession类型的pty实例
@@ -72,9 +140,16 @@ func (t *terminal) WsHandler(w http.ResponseWriter, r *http.Request) {
 		logger.Error("get pty failed: %v\n", err)
 		return
 	}
+	pty.username = username
+	pty.namespace = namespace
+	pty.podName = podName
+	pty.containerName = containerName
+	// 注册会话，websocket断开后可凭会话id重新连接
+	sessionRegistry.add(pty)
 	// 处理关闭
 	defer func() {
-		logger.Info("close session successfully!")
+		sessionRegistry.remove(pty.id)
+		logger.Info("close session " + pty.id + " successfully!")
 		pty.Close()
 	}()
 	/* 初始化pod所在的corev1资源组
@@ -91,7 +166,7 @@ func (t *terminal) WsHandler(w http.ResponseWriter, r *http.Request) {
 		SubResource("exec").
 		VersionedParams(&v1.PodExecOptions{
 			Container: containerName,
-			Command:   []string{"/bin/bash"},
+			Command:   []string{shell},
 			Stderr:    true,
 			Stdin:     true,
 			Stdout:    true,
@@ -105,7 +180,7 @@ func (t *terminal) WsHandler(w http.ResponseWriter, r *http.Request) {
 		logger.Error("建立SPDY连接失败," + err.Error())
 		return
 	}
-	// 与kubelet建立stream连接
+	// 与kubelet建立stream连接，websocket断开期间stream保持运行，直到会话关闭
 	err = executor.Stream(remotecommand.StreamOptions{
 		Stdout:            pty,
 		Stdin:             pty,
@@ -122,79 +197,273 @@ func (t *terminal) WsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// reattach 将新的websocket连接附加到已存在的会话，并回放断开期间的输出
+func (t *terminal) reattach(w http.ResponseWriter, r *http.Request, sessionId, username string) {
+	session, ok := sessionRegistry.get(sessionId)
+	if !ok {
+		http.Error(w, "终端会话 "+sessionId+"不存在或已过期", http.StatusNotFound)
+		return
+	}
+	if session.username != username {
+		http.Error(w, "无权限连接终端会话 "+sessionId, http.StatusForbidden)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("get pty failed: %v\n", err)
+		return
+	}
+	logger.Info("reattach session " + sessionId + " from " + r.RemoteAddr)
+	session.attach(conn, true)
+}
+
 // NewTerminalSession 该方法用于升级http协议至websocket，并new一个TerminalSession类型的对象返回
 func NewTerminalSession(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*TerminalSession, error) {
 	conn, err := upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	session := &TerminalSession{
-		wsConn:   conn,
-		sizeChan: make(chan remotecommand.TerminalSize),
-		doneChan: make(chan struct{}),
+		sizeChan:     make(chan remotecommand.TerminalSize),
+		doneChan:     make(chan struct{}),
+		inputChan:    make(chan []byte),
+		viewers:      make(map[string]*terminalViewer),
+		revoked:      make(map[string]bool),
+		id:           utilrand.String(16),
+		remoteAddr:   r.RemoteAddr,
+		startTime:    now,
+		lastActivity: now.UnixNano(),
 	}
+	session.attach(conn, false)
 	return session, nil
 }
 
+// attach 将websocket连接附加到会话，先告知客户端会话id，replay为true时回放缓存的输出
+// 若会话已有其他连接，旧连接会被关闭
+func (t *TerminalSession) attach(conn *websocket.Conn, replay bool) {
+	// 客户端需在pongWait内响应服务端的心跳，否则读取超时，连接随之断开
+	conn.SetReadDeadline(time.Now().Add(config.TerminalPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(config.TerminalPongWait))
+	})
+
+	t.lock.Lock()
+	if t.wsConn != nil {
+		t.wsConn.Close()
+	}
+	t.wsConn = conn
+	t.detachedAt = time.Time{}
+	t.writeLocked(TerminalMessage{Operation: "session", Data: t.id})
+	if replay && len(t.scrollback) > 0 {
+		t.writeLocked(TerminalMessage{Operation: "stdout", Data: string(t.scrollback)})
+	}
+	if len(t.viewers) > 0 {
+		t.notifyViewersLocked()
+	}
+	t.lock.Unlock()
+
+	go t.heartbeat(conn)
+	go t.readLoop(conn)
+}
+
+// detach 断开websocket连接，会话进入宽限期，等待客户端重新连接
+func (t *TerminalSession) detach(conn *websocket.Conn) {
+	t.lock.Lock()
+	if t.wsConn == conn {
+		t.wsConn = nil
+		t.detachedAt = time.Now()
+		logger.Info("terminal session " + t.id + " detached, waiting for reconnect")
+	}
+	t.lock.Unlock()
+	conn.Close()
+}
+
+// detachedSince 返回会话断开连接的时间，连接中时返回零值
+func (t *TerminalSession) detachedSince() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.detachedAt
+}
+
+// readLoop 读取web端的输入，将标准输入交给exec stream，直到连接断开或会话结束
+func (t *TerminalSession) readLoop(conn *websocket.Conn) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			logger.Error(errors.New("读取parse信息失败,错误信息," + err.Error()))
+			t.detach(conn)
+			return
+		}
+		// 反序列化
+		var msg TerminalMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			logger.Error(errors.New("读取parse信息失败,错误信息," + err.Error()))
+			continue
+		}
+		// 逻辑判断
+		switch msg.Operation {
+		// 如果是标准输入
+		case "stdin":
+			t.touch()
+			select {
+			case t.inputChan <- []byte(msg.Data):
+			case <-t.doneChan:
+				return
+			}
+		// 窗口调整大小
+		case "resize":
+			t.touch()
+			t.broadcastResize(msg.Rows, msg.Cols)
+			select {
+			case t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			case <-t.doneChan:
+				return
+			}
+		// ping	客户端保活，回复pong，不计入用户操作时间
+		case "ping":
+			t.writeMessage(TerminalMessage{Operation: "pong"})
+		default:
+			logger.Info(errors.New("无法确认的message类型,当前类型为 " + msg.Operation))
+			t.kill(fmt.Sprintf("unknown message type '%s'", msg.Operation))
+			return
+		}
+	}
+}
+
 // 用于读取web端的输入，接收web端输入的指令内容
 func (t *TerminalSession) Read(p []byte) (int, error) {
-	_, message, err := t.wsConn.ReadMessage()
-	if err != nil {
-		logger.Error(errors.New("读取parse信息失败,错误信息," + err.Error()))
-		return copy(p, config.EndOfTransmission), err
-	}
-	// 反序列化
-	var msg TerminalMessage
-	if err := json.Unmarshal([]byte(message), &msg); err != nil {
-		logger.Error(errors.New("读取parse信息失败,错误信息," + err.Error()))
-		// return 0, nil
-		return copy(p, config.EndOfTransmission), err
-	}
-	// 逻辑判断
-	switch msg.Operation {
-	// 如果是标准输入
-	case "stdin":
-		return copy(p, msg.Data), nil
-	// 窗口调整大小
-	case "resize":
-		t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}
-		return 0, nil
-	// ping	无内容交互
-	case "ping":
-		return 0, nil
-	default:
-		logger.Info(errors.New("无法确认的message类型,当前类型为 " + msg.Operation))
-		// return 0, nil
-		return copy(p, config.EndOfTransmission), fmt.Errorf("unknown message type '%s'",
-			msg.Operation)
-	}
-}
-
-// 写数据的方法，拿到apiserver的返回内容，向web端输出
+	if len(t.pending) == 0 {
+		select {
+		case data := <-t.inputChan:
+			t.pending = data
+		case <-t.doneChan:
+			return copy(p, config.EndOfTransmission), io.EOF
+		}
+	}
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// 写数据的方法，拿到apiserver的返回内容，写入缓存并向web端输出
+// 连接断开期间只写入缓存，重新连接后回放
 func (t *TerminalSession) Write(p []byte) (int, error) {
-	msg, err := json.Marshal(TerminalMessage{
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.appendScrollback(p)
+	t.broadcastLocked(TerminalMessage{Operation: "stdout", Data: string(p)})
+	if t.wsConn == nil {
+		return len(p), nil
+	}
+	if err := t.writeLocked(TerminalMessage{
 		Operation: "stdout",
 		Data:      string(p),
-	})
+	}); err != nil {
+		// 写入失败视为连接断开，exec stream保持运行
+		conn := t.wsConn
+		t.wsConn = nil
+		t.detachedAt = time.Now()
+		conn.Close()
+	}
+	return len(p), nil
+}
+
+// appendScrollback 追加输出到缓存，超出上限时丢弃最早的内容，调用方需持有lock
+func (t *TerminalSession) appendScrollback(p []byte) {
+	t.scrollback = append(t.scrollback, p...)
+	if overflow := len(t.scrollback) - config.TerminalScrollbackSize; overflow > 0 {
+		// 避免从多字节字符中间截断
+		for overflow < len(t.scrollback) && !utf8.RuneStart(t.scrollback[overflow]) {
+			overflow++
+		}
+		t.scrollback = append([]byte(nil), t.scrollback[overflow:]...)
+	}
+}
+
+// writeMessage 序列化TerminalMessage并写入当前websocket连接
+func (t *TerminalSession) writeMessage(message TerminalMessage) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.writeLocked(message)
+}
+
+// writeLocked 同writeMessage，调用方需持有lock
+func (t *TerminalSession) writeLocked(message TerminalMessage) error {
+	if t.wsConn == nil {
+		return nil
+	}
+	msg, err := json.Marshal(message)
 	if err != nil {
 		logger.Error("write parse message err: %v", err)
-		return 0, err
+		return err
 	}
 	if err := t.wsConn.WriteMessage(websocket.TextMessage, msg); err != nil {
 		logger.Info("write message err: %v", err)
-		return 0, err
+		return err
 	}
-	return len(p), nil
+	return nil
+}
+
+// heartbeat 定时向客户端发送websocket ping帧，直到连接被替换、断开或会话结束
+func (t *TerminalSession) heartbeat(conn *websocket.Conn) {
+	ticker := time.NewTicker(config.TerminalHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.lock.Lock()
+			current := t.wsConn == conn
+			t.lock.Unlock()
+			if !current {
+				return
+			}
+			// WriteControl可以与其他写方法并发调用
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.TerminalWriteWait)); err != nil {
+				logger.Info("terminal session " + t.id + " heartbeat failed, " + err.Error())
+				t.detach(conn)
+				return
+			}
+		case <-t.doneChan:
+			return
+		}
+	}
+}
+
+// touch 记录最后一次用户操作的时间
+func (t *TerminalSession) touch() {
+	atomic.StoreInt64(&t.lastActivity, time.Now().UnixNano())
+}
+
+// kill 向web端输出关闭原因后关闭会话
+func (t *TerminalSession) kill(reason string) {
+	t.writeMessage(TerminalMessage{Operation: "stdout", Data: "\r\n" + reason + "\r\n"})
+	t.Close()
 }
 
 // Done 标记关闭doneChan,关闭后触发退出终端
 func (t *TerminalSession) Done() {
-	close(t.doneChan)
+	t.doneOnce.Do(func() {
+		close(t.doneChan)
+	})
 }
 
-// Close 用于关闭websocket连接
-func (t *TerminalSession) Close() error {
-	return t.wsConn.Close()
+// Close 用于关闭会话及websocket连接
+func (t *TerminalSession) Close() (err error) {
+	t.closeOnce.Do(func() {
+		t.Done()
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		for id, viewer := range t.viewers {
+			viewer.conn.Close()
+			delete(t.viewers, id)
+		}
+		if t.wsConn != nil {
+			err = t.wsConn.Close()
+			t.wsConn = nil
+		}
+	})
+	return err
 }
 
 // Next 获取web端是否resize,以及是否退出终端
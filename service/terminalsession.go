@@ -0,0 +1,168 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"errors"
+	"github.com/wonderivan/logger"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TerminalSessionInfo 定义终端会话的展示信息，用于会话列表接口
+type TerminalSessionInfo struct {
+	ID            string    `json:"id"`
+	Username      string    `json:"username"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"podName"`
+	ContainerName string    `json:"containerName"`
+	RemoteAddr    string    `json:"remoteAddr"`
+	StartTime     time.Time `json:"startTime"`
+	LastActivity  time.Time `json:"lastActivity"`
+	// Detached 为true时表示websocket已断开，会话在宽限期内等待重新连接
+	Detached bool `json:"detached"`
+	// Shared 为true时表示会话已开启只读共享，Viewers为当前观察者数量
+	Shared  bool `json:"shared"`
+	Viewers int  `json:"viewers"`
+}
+
+// 终端会话注册表，记录所有活跃的TerminalSession
+var sessionRegistry = &terminalRegistry{
+	sessions: make(map[string]*TerminalSession),
+}
+
+// 定义terminalRegistry结构体，lock保护sessions的并发读写
+type terminalRegistry struct {
+	lock     sync.RWMutex
+	sessions map[string]*TerminalSession
+}
+
+// add 注册会话
+func (r *terminalRegistry) add(session *TerminalSession) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sessions[session.id] = session
+}
+
+// remove 注销会话
+func (r *terminalRegistry) remove(id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.sessions, id)
+}
+
+// get 根据id获取会话
+func (r *terminalRegistry) get(id string) (*TerminalSession, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	session, ok := r.sessions[id]
+	return session, ok
+}
+
+// list 获取所有会话
+func (r *terminalRegistry) list() []*TerminalSession {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	sessions := make([]*TerminalSession, 0, len(r.sessions))
+	for _, session := range r.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// info 将TerminalSession转换为TerminalSessionInfo
+func (t *TerminalSession) info() *TerminalSessionInfo {
+	t.lock.Lock()
+	shared, viewers := t.shareToken != "", len(t.viewers)
+	t.lock.Unlock()
+	return &TerminalSessionInfo{
+		ID:            t.id,
+		Username:      t.username,
+		Namespace:     t.namespace,
+		PodName:       t.podName,
+		ContainerName: t.containerName,
+		RemoteAddr:    t.remoteAddr,
+		StartTime:     t.startTime,
+		LastActivity:  time.Unix(0, atomic.LoadInt64(&t.lastActivity)),
+		Detached:      !t.detachedSince().IsZero(),
+		Shared:        shared,
+		Viewers:       viewers,
+	}
+}
+
+// ListSessions 获取活跃的终端会话列表，按开始时间倒序排列，namespace为空时返回全部
+// 管理员获取所有用户的会话，其他用户只获取自己的会话
+func (t *terminal) ListSessions(namespace, username string) (sessions []*TerminalSessionInfo) {
+	admin := isTerminalAdmin(username)
+	sessions = make([]*TerminalSessionInfo, 0)
+	for _, session := range sessionRegistry.list() {
+		if namespace != "" && session.namespace != namespace {
+			continue
+		}
+		if !admin && session.username != username {
+			continue
+		}
+		sessions = append(sessions, session.info())
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[j].StartTime.Before(sessions[i].StartTime)
+	})
+	return sessions
+}
+
+// KillSession 强制关闭终端会话，管理员可以关闭任意会话，其他用户只能关闭自己的会话
+func (t *terminal) KillSession(id, username string) (err error) {
+	session, ok := sessionRegistry.get(id)
+	if !ok {
+		logger.Error(errors.New("终端会话 " + id + "不存在"))
+		return errors.New("终端会话 " + id + "不存在")
+	}
+	admin := isTerminalAdmin(username)
+	if !admin && session.username != username {
+		logger.Error(errors.New("用户 " + username + "无权限关闭终端会话 " + id))
+		return &AccessDeniedError{message: "用户 " + username + "无权限关闭终端会话 " + id}
+	}
+	logger.Info("terminal session " + id + " killed by " + username)
+	if admin && session.username != username {
+		session.kill("会话已被管理员关闭")
+	} else {
+		session.kill("会话已被关闭")
+	}
+	return nil
+}
+
+// isTerminalAdmin 判断用户是否为终端会话管理员
+func isTerminalAdmin(username string) bool {
+	for _, admin := range config.TerminalAdminUsers {
+		if admin == username {
+			return true
+		}
+	}
+	return false
+}
+
+// RunSessionReaper 定时检查终端会话，关闭空闲超时、超出最大时长以及断开后未在宽限期内重新连接的会话
+// 需要在服务启动时以goroutine方式运行
+func (t *terminal) RunSessionReaper() {
+	ticker := time.NewTicker(config.TerminalReapInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		now := time.Now()
+		for _, session := range sessionRegistry.list() {
+			lastActivity := time.Unix(0, atomic.LoadInt64(&session.lastActivity))
+			detachedAt := session.detachedSince()
+			switch {
+			case !detachedAt.IsZero() && now.Sub(detachedAt) > config.TerminalReconnectGrace:
+				logger.Info("terminal session " + session.id + " reconnect grace expired")
+				session.Close()
+			case now.Sub(session.startTime) > config.TerminalMaxDuration:
+				logger.Info("terminal session " + session.id + " exceeded max duration")
+				session.kill("会话超出最大时长" + config.TerminalMaxDuration.String() + ",已关闭")
+			case now.Sub(lastActivity) > config.TerminalIdleTimeout:
+				logger.Info("terminal session " + session.id + " idle timeout")
+				session.kill("会话空闲超过" + config.TerminalIdleTimeout.String() + ",已关闭")
+			}
+		}
+	}
+}
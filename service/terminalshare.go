@@ -0,0 +1,248 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/wonderivan/logger"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/tools/remotecommand"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// 终端会话的只读共享
+// 会话所有者通过Share获取共享token，已登录的观察者携带sessionId、shareToken以及mode=view连接websocket，
+// 之后会收到与所有者相同的stdout输出和resize事件，观察者发送的输入会被忽略
+
+// TerminalViewer 定义观察者的展示信息
+type TerminalViewer struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	RemoteAddr string    `json:"remoteAddr"`
+	JoinedAt   time.Time `json:"joinedAt"`
+}
+
+// 定义terminalViewer结构体，conn为观察者的websocket连接
+type terminalViewer struct {
+	TerminalViewer
+	conn *websocket.Conn
+}
+
+// TerminalShare 定义共享会话的返回内容
+type TerminalShare struct {
+	SessionId  string `json:"sessionId"`
+	ShareToken string `json:"shareToken"`
+}
+
+// Share 开启会话的只读共享，返回观察者连接需要的token，重复调用返回同一个token
+func (t *terminal) Share(sessionId, username string) (share *TerminalShare, err error) {
+	session, err := getOwnedSession(sessionId, username)
+	if err != nil {
+		return nil, err
+	}
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if session.shareToken == "" {
+		session.shareToken = utilrand.String(32)
+	}
+	return &TerminalShare{
+		SessionId:  session.id,
+		ShareToken: session.shareToken,
+	}, nil
+}
+
+// ListViewers 获取会话的观察者列表
+func (t *terminal) ListViewers(sessionId, username string) (viewers []*TerminalViewer, err error) {
+	session, err := getOwnedSession(sessionId, username)
+	if err != nil {
+		return nil, err
+	}
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	return session.viewerListLocked(), nil
+}
+
+// RevokeViewer 移除观察者，只断开viewerId对应的连接，同一用户的其他连接不受影响
+// ban为true时同时禁止该用户再次加入会话
+func (t *terminal) RevokeViewer(sessionId, viewerId, username string, ban bool) (err error) {
+	session, err := getOwnedSession(sessionId, username)
+	if err != nil {
+		return err
+	}
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	viewer, ok := session.viewers[viewerId]
+	if !ok {
+		logger.Error(errors.New("观察者 " + viewerId + "不存在"))
+		return errors.New("观察者 " + viewerId + "不存在")
+	}
+	if ban {
+		session.revoked[viewer.Username] = true
+	}
+	session.removeViewerLocked(viewerId)
+	logger.Info("terminal session " + sessionId + " viewer " + viewerId + "(" + viewer.Username + ") revoked")
+	return nil
+}
+
+// getOwnedSession 获取会话并校验所有者
+func getOwnedSession(sessionId, username string) (*TerminalSession, error) {
+	session, ok := sessionRegistry.get(sessionId)
+	if !ok {
+		logger.Error(errors.New("终端会话 " + sessionId + "不存在"))
+		return nil, errors.New("终端会话 " + sessionId + "不存在")
+	}
+	if session.username != username {
+		logger.Error(errors.New("用户 " + username + "不是终端会话 " + sessionId + "的所有者"))
+		return nil, errors.New("用户 " + username + "不是终端会话 " + sessionId + "的所有者")
+	}
+	return session, nil
+}
+
+// watch 以只读观察者身份加入共享会话，观察者需要携带token
+func (t *terminal) watch(w http.ResponseWriter, r *http.Request, sessionId, username, shareToken string) {
+	// 观察者必须登录，按用户禁止再次加入才有意义
+	if username == "anonymous" {
+		http.Error(w, "观看终端会话需要登录", http.StatusUnauthorized)
+		return
+	}
+	session, ok := sessionRegistry.get(sessionId)
+	if !ok {
+		http.Error(w, "终端会话 "+sessionId+"不存在或已过期", http.StatusNotFound)
+		return
+	}
+	session.lock.Lock()
+	allowed := session.shareToken != "" && session.shareToken == shareToken && !session.revoked[username]
+	session.lock.Unlock()
+	if !allowed {
+		http.Error(w, "无权限观看终端会话 "+sessionId, http.StatusForbidden)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("get pty failed: %v\n", err)
+		return
+	}
+	viewer := &terminalViewer{
+		TerminalViewer: TerminalViewer{
+			ID:         utilrand.String(8),
+			Username:   username,
+			RemoteAddr: r.RemoteAddr,
+			JoinedAt:   time.Now(),
+		},
+		conn: conn,
+	}
+	conn.SetReadDeadline(time.Now().Add(config.TerminalPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(config.TerminalPongWait))
+	})
+	logger.Info("terminal session " + sessionId + " viewer " + username + " joined from " + r.RemoteAddr)
+	session.addViewer(viewer)
+	go session.viewerHeartbeat(viewer)
+
+	// 观察者只读，仅读取消息以感知连接断开
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	session.lock.Lock()
+	session.removeViewerLocked(viewer.ID)
+	session.lock.Unlock()
+}
+
+// addViewer 注册观察者，回放缓存的输出并同步终端大小，同时通知所有者
+func (t *TerminalSession) addViewer(viewer *terminalViewer) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.viewers[viewer.ID] = viewer
+	t.writeViewerLocked(viewer, TerminalMessage{Operation: "session", Data: t.id})
+	if len(t.scrollback) > 0 {
+		t.writeViewerLocked(viewer, TerminalMessage{Operation: "stdout", Data: string(t.scrollback)})
+	}
+	if t.lastSize != nil {
+		t.writeViewerLocked(viewer, TerminalMessage{Operation: "resize", Rows: t.lastSize.Height, Cols: t.lastSize.Width})
+	}
+	t.notifyViewersLocked()
+}
+
+// removeViewerLocked 移除观察者并通知所有者，调用方需持有lock
+func (t *TerminalSession) removeViewerLocked(viewerId string) {
+	viewer, ok := t.viewers[viewerId]
+	if !ok {
+		return
+	}
+	delete(t.viewers, viewerId)
+	viewer.conn.Close()
+	t.notifyViewersLocked()
+}
+
+// viewerListLocked 获取观察者列表，按加入时间排序，调用方需持有lock
+func (t *TerminalSession) viewerListLocked() []*TerminalViewer {
+	viewers := make([]*TerminalViewer, 0, len(t.viewers))
+	for _, viewer := range t.viewers {
+		info := viewer.TerminalViewer
+		viewers = append(viewers, &info)
+	}
+	sort.Slice(viewers, func(i, j int) bool {
+		return viewers[i].JoinedAt.Before(viewers[j].JoinedAt)
+	})
+	return viewers
+}
+
+// notifyViewersLocked 向所有者推送最新的观察者列表，调用方需持有lock
+func (t *TerminalSession) notifyViewersLocked() {
+	data, err := json.Marshal(t.viewerListLocked())
+	if err != nil {
+		logger.Error("write parse message err: %v", err)
+		return
+	}
+	t.writeLocked(TerminalMessage{Operation: "viewers", Data: string(data)})
+}
+
+// broadcastResize 记录终端大小并同步给观察者
+func (t *TerminalSession) broadcastResize(rows, cols uint16) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.lastSize = &remotecommand.TerminalSize{Width: cols, Height: rows}
+	t.broadcastLocked(TerminalMessage{Operation: "resize", Rows: rows, Cols: cols})
+}
+
+// broadcastLocked 向所有观察者发送消息，写入失败的观察者会被移除，调用方需持有lock
+func (t *TerminalSession) broadcastLocked(message TerminalMessage) {
+	for id, viewer := range t.viewers {
+		if err := t.writeViewerLocked(viewer, message); err != nil {
+			t.removeViewerLocked(id)
+		}
+	}
+}
+
+// writeViewerLocked 向观察者写入消息，设置写超时避免慢连接阻塞所有者，调用方需持有lock
+func (t *TerminalSession) writeViewerLocked(viewer *terminalViewer, message TerminalMessage) error {
+	msg, err := json.Marshal(message)
+	if err != nil {
+		logger.Error("write parse message err: %v", err)
+		return err
+	}
+	viewer.conn.SetWriteDeadline(time.Now().Add(config.TerminalWriteWait))
+	return viewer.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// viewerHeartbeat 定时向观察者发送websocket ping帧，直到观察者离开或会话结束
+func (t *TerminalSession) viewerHeartbeat(viewer *terminalViewer) {
+	ticker := time.NewTicker(config.TerminalHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := viewer.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.TerminalWriteWait)); err != nil {
+				viewer.conn.Close()
+				return
+			}
+		case <-t.doneChan:
+			return
+		}
+	}
+}
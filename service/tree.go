@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wonderivan/logger"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	nwv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"strings"
+)
+
+// 工作负载的owner关系树，向下按ownerReference查找子资源(Deployment → ReplicaSet → Pod、CronJob → Job → Pod等)，
+// 向上按controller ownerReference查找owner链，并按selector关联Service、按backend关联Ingress
+
+// Tree 定义Tree全局变量
+var Tree tree
+
+// 定义tree结构体
+type tree struct{}
+
+// treeKinds 支持查询owner关系树的资源类型
+var treeKinds = map[string]schema.GroupVersionResource{
+	"deployment":  appsv1.SchemeGroupVersion.WithResource("deployments"),
+	"statefulset": appsv1.SchemeGroupVersion.WithResource("statefulsets"),
+	"daemonset":   appsv1.SchemeGroupVersion.WithResource("daemonsets"),
+	"replicaset":  appsv1.SchemeGroupVersion.WithResource("replicasets"),
+	"job":         batchv1.SchemeGroupVersion.WithResource("jobs"),
+	"cronjob":     batchv1.SchemeGroupVersion.WithResource("cronjobs"),
+	"pod":         corev1.SchemeGroupVersion.WithResource("pods"),
+}
+
+// treeChildKinds 可能作为子资源出现的资源类型
+var treeChildKinds = []schema.GroupVersionResource{
+	appsv1.SchemeGroupVersion.WithResource("replicasets"),
+	batchv1.SchemeGroupVersion.WithResource("jobs"),
+	corev1.SchemeGroupVersion.WithResource("pods"),
+}
+
+// treeNodeEvents 每个节点返回的最近事件数量
+const treeNodeEvents = 5
+
+// treeMaxOwnerDepth 向上查找owner链的最大层数，避免ownerReference成环时死循环
+const treeMaxOwnerDepth = 10
+
+// TreeNode 定义owner关系树的节点，Status为资源的简要状态，如pod的phase、deployment的就绪副本数
+type TreeNode struct {
+	Kind              string           `json:"kind"`
+	Name              string           `json:"name"`
+	Namespace         string           `json:"namespace"`
+	UID               types.UID        `json:"uid"`
+	Status            string           `json:"status"`
+	CreationTimestamp metav1.Time      `json:"creationTimestamp"`
+	Events            []*ResourceEvent `json:"events,omitempty"`
+	Children          []*TreeNode      `json:"children,omitempty"`
+}
+
+// WorkloadTreeResp 定义owner关系树的返回内容
+// Owners为从直接owner到顶层owner的owner链，Root为查询的资源及其子资源
+// Services为selector匹配pod标签的Service，Ingresses为backend指向这些Service的Ingress，其Children为对应的Service
+type WorkloadTreeResp struct {
+	Owners    []*TreeNode `json:"owners"`
+	Root      *TreeNode   `json:"root"`
+	Services  []*TreeNode `json:"services"`
+	Ingresses []*TreeNode `json:"ingresses"`
+}
+
+// GetWorkloadTree 获取资源的owner关系树，kind不区分大小写，可选值为deployment、statefulset、daemonset、
+// replicaset、job、cronjob、pod
+func (t *tree) GetWorkloadTree(kind, name, namespace string) (treeResp *WorkloadTreeResp, err error) {
+	gvr, ok := treeKinds[strings.ToLower(kind)]
+	if !ok {
+		logger.Error(errors.New("不支持的资源类型 " + kind + ",可选值为deployment、statefulset、daemonset、replicaset、job、cronjob、pod"))
+		return nil, errors.New("不支持的资源类型 " + kind + ",可选值为deployment、statefulset、daemonset、replicaset、job、cronjob、pod")
+	}
+	obj, err := K8s.DynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取" + kind + " " + name + "失败,错误信息 " + err.Error())
+	}
+
+	// 一次性获取namespace下可能的子资源，按controller owner的uid分组
+	children := map[types.UID][]*unstructured.Unstructured{}
+	for _, childGvr := range treeChildKinds {
+		list, err := K8s.DynamicClient.Resource(childGvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			logger.Error(errors.New("获取" + childGvr.Resource + "列表失败,错误信息 " + err.Error()))
+			return nil, errors.New("获取" + childGvr.Resource + "列表失败,错误信息 " + err.Error())
+		}
+		for i := range list.Items {
+			if controllerRef := metav1.GetControllerOf(&list.Items[i]); controllerRef != nil {
+				children[controllerRef.UID] = append(children[controllerRef.UID], &list.Items[i])
+			}
+		}
+	}
+	eventList, err := K8s.ClientSet.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取namespace " + namespace + "的事件失败,错误信息 " + err.Error()))
+		return nil, errors.New("获取namespace " + namespace + "的事件失败,错误信息 " + err.Error())
+	}
+	events := map[types.UID][]corev1.Event{}
+	for _, event := range eventList.Items {
+		events[event.InvolvedObject.UID] = append(events[event.InvolvedObject.UID], event)
+	}
+
+	// 子资源中pod的标签以及工作负载pod模板的标签，用于匹配Service
+	podLabels := []labels.Set{templateLabels(obj)}
+	treeResp = &WorkloadTreeResp{Owners: []*TreeNode{}, Services: []*TreeNode{}, Ingresses: []*TreeNode{}}
+	treeResp.Root = t.build(obj, children, events, &podLabels)
+	if treeResp.Owners, err = t.owners(obj); err != nil {
+		return nil, err
+	}
+	if err := t.related(treeResp, namespace, podLabels); err != nil {
+		return nil, err
+	}
+	return treeResp, nil
+}
+
+// build 递归组装节点及其子资源，同时收集pod的标签
+func (t *tree) build(obj *unstructured.Unstructured, children map[types.UID][]*unstructured.Unstructured, events map[types.UID][]corev1.Event, podLabels *[]labels.Set) *TreeNode {
+	node := &TreeNode{
+		Kind:              obj.GetKind(),
+		Name:              obj.GetName(),
+		Namespace:         obj.GetNamespace(),
+		UID:               obj.GetUID(),
+		Status:            treeNodeStatus(obj),
+		CreationTimestamp: obj.GetCreationTimestamp(),
+		Events:            recentEvents(events[obj.GetUID()], treeNodeEvents),
+	}
+	if obj.GetKind() == "Pod" {
+		*podLabels = append(*podLabels, obj.GetLabels())
+	}
+	for _, child := range children[obj.GetUID()] {
+		node.Children = append(node.Children, t.build(child, children, events, podLabels))
+	}
+	return node
+}
+
+// owners 沿controller ownerReference向上查找owner链，owner不存在时以NotFound状态结束
+// owner的资源类型无法识别(如CRD已被删除)时以Unresolved状态结束，owner为集群级资源(如mirror pod的Node)时不带namespace查询
+func (t *tree) owners(obj *unstructured.Unstructured) ([]*TreeNode, error) {
+	owners := []*TreeNode{}
+	namespace := obj.GetNamespace()
+	controllerRef := metav1.GetControllerOf(obj)
+	for depth := 0; controllerRef != nil && depth < treeMaxOwnerDepth; depth++ {
+		node := &TreeNode{Kind: controllerRef.Kind, Name: controllerRef.Name, Namespace: namespace, UID: controllerRef.UID}
+		owners = append(owners, node)
+		gv, err := schema.ParseGroupVersion(controllerRef.APIVersion)
+		if err != nil {
+			logger.Error(errors.New("解析owner的apiVersion " + controllerRef.APIVersion + "失败,错误信息 " + err.Error()))
+			node.Status = "Unresolved"
+			break
+		}
+		mapping, err := restMapping(gv.WithKind(controllerRef.Kind).GroupKind(), gv.Version)
+		if err != nil {
+			logger.Error(errors.New("获取资源类型 " + controllerRef.Kind + "的映射失败,错误信息 " + err.Error()))
+			node.Status = "Unresolved"
+			break
+		}
+		client := K8s.MetadataClient.Resource(mapping.Resource)
+		var owner *metav1.PartialObjectMetadata
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			node.Namespace = ""
+			owner, err = client.Get(context.TODO(), controllerRef.Name, metav1.GetOptions{})
+		} else {
+			owner, err = client.Namespace(namespace).Get(context.TODO(), controllerRef.Name, metav1.GetOptions{})
+		}
+		if apierrors.IsNotFound(err) {
+			// owner已被删除时子资源会被垃圾回收，这里只标记状态
+			node.Status = "NotFound"
+			break
+		}
+		if err != nil {
+			logger.Error(errors.New("获取" + controllerRef.Kind + " " + controllerRef.Name + "失败,错误信息 " + err.Error()))
+			return nil, errors.New("获取" + controllerRef.Kind + " " + controllerRef.Name + "失败,错误信息 " + err.Error())
+		}
+		node.CreationTimestamp = owner.CreationTimestamp
+		namespace = owner.GetNamespace()
+		controllerRef = metav1.GetControllerOf(owner)
+	}
+	return owners, nil
+}
+
+// related 查找selector匹配pod标签的Service，以及backend指向这些Service的Ingress
+func (t *tree) related(treeResp *WorkloadTreeResp, namespace string, podLabels []labels.Set) error {
+	serviceList, err := K8s.ClientSet.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取service列表失败,错误信息 " + err.Error()))
+		return errors.New("获取service列表失败,错误信息 " + err.Error())
+	}
+	services := map[string]*TreeNode{}
+	for _, service := range serviceList.Items {
+		// 没有selector的service由用户手动维护endpoints，不属于任何工作负载
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		for _, set := range podLabels {
+			if len(set) > 0 && selector.Matches(set) {
+				node := &TreeNode{
+					Kind:              "Service",
+					Name:              service.Name,
+					Namespace:         service.Namespace,
+					UID:               service.UID,
+					Status:            string(service.Spec.Type),
+					CreationTimestamp: service.CreationTimestamp,
+				}
+				services[service.Name] = node
+				treeResp.Services = append(treeResp.Services, node)
+				break
+			}
+		}
+	}
+	if len(services) == 0 {
+		return nil
+	}
+	ingressList, err := K8s.ClientSet.NetworkingV1().Ingresses(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error(errors.New("获取ingress列表失败,错误信息 " + err.Error()))
+		return errors.New("获取ingress列表失败,错误信息 " + err.Error())
+	}
+	for _, ingress := range ingressList.Items {
+		node := &TreeNode{
+			Kind:              "Ingress",
+			Name:              ingress.Name,
+			Namespace:         ingress.Namespace,
+			UID:               ingress.UID,
+			CreationTimestamp: ingress.CreationTimestamp,
+		}
+		for _, backend := range ingressServiceBackends(&ingress) {
+			if service, ok := services[backend]; ok {
+				node.Children = append(node.Children, service)
+			}
+		}
+		if len(node.Children) > 0 {
+			treeResp.Ingresses = append(treeResp.Ingresses, node)
+		}
+	}
+	return nil
+}
+
+// ingressServiceBackends 获取ingress的默认backend及各规则backend指向的service名称，已去重
+func ingressServiceBackends(ingress *nwv1.Ingress) []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(backend *nwv1.IngressBackend) {
+		if backend == nil || backend.Service == nil || seen[backend.Service.Name] {
+			return
+		}
+		seen[backend.Service.Name] = true
+		names = append(names, backend.Service.Name)
+	}
+	add(ingress.Spec.DefaultBackend)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			add(&rule.HTTP.Paths[i].Backend)
+		}
+	}
+	return names
+}
+
+// templateLabels 获取工作负载pod模板的标签，pod返回自身的标签
+func templateLabels(obj *unstructured.Unstructured) labels.Set {
+	var path []string
+	switch obj.GetKind() {
+	case "Pod":
+		return obj.GetLabels()
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "metadata", "labels"}
+	default:
+		path = []string{"spec", "template", "metadata", "labels"}
+	}
+	result, _, _ := unstructured.NestedStringMap(obj.Object, path...)
+	return result
+}
+
+// treeNodeStatus 获取资源的简要状态
+func treeNodeStatus(obj *unstructured.Unstructured) string {
+	switch obj.GetKind() {
+	case "Pod":
+		if obj.GetDeletionTimestamp() != nil {
+			return "Terminating"
+		}
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		return phase
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		return fmt.Sprintf("%d/%d ready", ready, replicas)
+	case "DaemonSet":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberReady")
+		return fmt.Sprintf("%d/%d ready", ready, desired)
+	case "Job":
+		job := &batchv1.Job{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, job); err != nil {
+			return ""
+		}
+		status, _ := jobStatusOf(job)
+		return status
+	case "CronJob":
+		if suspend, _, _ := unstructured.NestedBool(obj.Object, "spec", "suspend"); suspend {
+			return JobSuspended
+		}
+		active, _, _ := unstructured.NestedSlice(obj.Object, "status", "active")
+		return fmt.Sprintf("%d active", len(active))
+	}
+	return ""
+}
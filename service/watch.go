@@ -0,0 +1,330 @@
+package service
+
+import (
+	"NativeSphere/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/wonderivan/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 资源watch，客户端通过websocket订阅(资源类型、namespace、选择器)，服务端使用k8s watch api实时推送ADDED、MODIFIED、DELETED事件
+// 一个连接上可以有多个订阅，以客户端指定的id区分；每个事件都带有resourceVersion，
+// 客户端重新连接后携带最后收到的resourceVersion订阅即可从断开处继续，resourceVersion过期时返回410，需要重新获取列表
+
+// Watch 定义Watch全局变量
+var Watch resourceWatch
+
+// 定义resourceWatch结构体
+type resourceWatch struct{}
+
+// 客户端发送的操作类型
+const (
+	WatchSubscribe   = "subscribe"
+	WatchUnsubscribe = "unsubscribe"
+)
+
+// 除k8s的ADDED、MODIFIED、DELETED、BOOKMARK外，服务端推送的事件类型
+const (
+	WatchSubscribed   = "SUBSCRIBED"
+	WatchUnsubscribed = "UNSUBSCRIBED"
+	WatchError        = "ERROR"
+)
+
+// WatchRequest 定义客户端发送的订阅消息
+// ResourceType指定资源类型，ResourceVersion为空时先推送现有对象的ADDED事件再推送后续变化
+type WatchRequest struct {
+	Operation string `json:"operation"`
+	Id        string `json:"id"`
+	ResourceType
+	Namespace       string `json:"namespace"`
+	LabelSelector   string `json:"labelSelector"`
+	FieldSelector   string `json:"fieldSelector"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// WatchEvent 定义推送给客户端的事件，Type为ERROR时Code为对应的http状态码
+// BOOKMARK事件只携带resourceVersion，用于客户端记录重新连接时的位置
+type WatchEvent struct {
+	Id              string                 `json:"id"`
+	Type            string                 `json:"type"`
+	ResourceVersion string                 `json:"resourceVersion,omitempty"`
+	Object          map[string]interface{} `json:"object,omitempty"`
+	Code            int                    `json:"code,omitempty"`
+	Message         string                 `json:"message,omitempty"`
+}
+
+// watchConn 定义一个websocket连接及其上的订阅
+// lock保证websocket同一时间只有一个写入者，subLock保护subscriptions
+type watchConn struct {
+	conn          *websocket.Conn
+	username      string
+	ctx           context.Context
+	cancel        context.CancelFunc
+	lock          sync.Mutex
+	subLock       sync.Mutex
+	subscriptions map[string]*watchSubscription
+}
+
+// watchSubscription 定义连接上的一个订阅，cancel用于停止该订阅的watch
+type watchSubscription struct {
+	cancel context.CancelFunc
+}
+
+// WsHandler 定义资源watch的websocket handler方法
+// 请求示例: ws://127.0.0.1:8081/ws/watch?token=xxx
+// 订阅消息示例: {"operation":"subscribe","id":"pods","resource":"pods","namespace":"default","labelSelector":"app=nginx","resourceVersion":"12345"}
+func (w *resourceWatch) WsHandler(rw http.ResponseWriter, r *http.Request) {
+	// 身份识别需要在升级websocket之前完成，失败时直接返回http错误码，订阅时再按资源类型校验RBAC
+	username, err := getRequestUser(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	conn, err := upgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		logger.Error("升级websocket协议失败," + err.Error())
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &watchConn{
+		conn:          conn,
+		username:      username,
+		ctx:           ctx,
+		cancel:        cancel,
+		subscriptions: map[string]*watchSubscription{},
+	}
+	// 连接断开时取消所有订阅
+	defer c.close()
+	logger.Info("watch connected, user: %s, remote: %s\n", username, r.RemoteAddr)
+
+	go c.heartbeat()
+	c.readLoop()
+}
+
+// readLoop 读取客户端的订阅消息，直到连接断开
+func (c *watchConn) readLoop() {
+	c.conn.SetReadDeadline(time.Now().Add(config.WatchPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(config.WatchPongWait))
+	})
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(config.WatchPongWait))
+		request := &WatchRequest{}
+		if err := json.Unmarshal(message, request); err != nil {
+			c.sendError("", http.StatusBadRequest, "解析订阅消息失败,错误信息 "+err.Error())
+			continue
+		}
+		switch request.Operation {
+		case WatchSubscribe:
+			c.subscribe(request)
+		case WatchUnsubscribe:
+			c.unsubscribe(request.Id)
+		default:
+			c.sendError(request.Id, http.StatusBadRequest, "不支持的操作类型 "+request.Operation+",可选值为subscribe、unsubscribe")
+		}
+	}
+}
+
+// subscribe 校验订阅参数及用户权限，并启动watch
+func (c *watchConn) subscribe(request *WatchRequest) {
+	if request.Id == "" {
+		c.sendError("", http.StatusBadRequest, "订阅id不能为空")
+		return
+	}
+	gvr, namespaced, err := Resource.resolve(&request.ResourceType)
+	if err != nil {
+		c.sendError(request.Id, http.StatusBadRequest, err.Error())
+		return
+	}
+	var client dynamic.ResourceInterface
+	if namespaced {
+		client = K8s.DynamicClient.Resource(gvr).Namespace(request.Namespace)
+	} else {
+		request.Namespace = ""
+		client = K8s.DynamicClient.Resource(gvr)
+	}
+	if err := checkAccess(c.username, request.Namespace, "watch", gvr.Group, gvr.Resource, "", ""); err != nil {
+		code := http.StatusInternalServerError
+		if IsAccessDenied(err) {
+			code = http.StatusForbidden
+		}
+		c.sendError(request.Id, code, err.Error())
+		return
+	}
+
+	c.subLock.Lock()
+	if _, ok := c.subscriptions[request.Id]; ok {
+		c.subLock.Unlock()
+		c.sendError(request.Id, http.StatusBadRequest, "订阅id "+request.Id+"已存在")
+		return
+	}
+	if len(c.subscriptions) >= config.WatchMaxSubscriptions {
+		c.subLock.Unlock()
+		c.sendError(request.Id, http.StatusBadRequest, "订阅数量超过上限")
+		return
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	subscription := &watchSubscription{cancel: cancel}
+	c.subscriptions[request.Id] = subscription
+	c.subLock.Unlock()
+
+	logger.Info("watch subscribe user: %s, id: %s, resource: %s, namespace: %s, resourceVersion: %s\n",
+		c.username, request.Id, gvr.String(), request.Namespace, request.ResourceVersion)
+	c.send(&WatchEvent{Id: request.Id, Type: WatchSubscribed, ResourceVersion: request.ResourceVersion})
+	go c.run(ctx, request, client, subscription)
+}
+
+// unsubscribe 取消订阅
+func (c *watchConn) unsubscribe(id string) {
+	c.subLock.Lock()
+	subscription, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.subLock.Unlock()
+	if !ok {
+		c.sendError(id, http.StatusNotFound, "订阅id "+id+"不存在")
+		return
+	}
+	subscription.cancel()
+	c.send(&WatchEvent{Id: id, Type: WatchUnsubscribed})
+}
+
+// run 执行watch并推送事件，apiserver会定期关闭watch连接，此时从最后的resourceVersion重新watch
+// 出现错误时推送ERROR事件并结束订阅
+func (c *watchConn) run(ctx context.Context, request *WatchRequest, client dynamic.ResourceInterface, subscription *watchSubscription) {
+	defer func() {
+		// 取消订阅后客户端可能已使用相同的id重新订阅，只删除自己的订阅
+		c.subLock.Lock()
+		if c.subscriptions[request.Id] == subscription {
+			delete(c.subscriptions, request.Id)
+		}
+		c.subLock.Unlock()
+		subscription.cancel()
+	}()
+	resourceVersion := request.ResourceVersion
+	for {
+		watcher, err := client.Watch(ctx, metav1.ListOptions{
+			LabelSelector:       request.LabelSelector,
+			FieldSelector:       request.FieldSelector,
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				c.sendWatchError(request.Id, resourceVersion, err)
+			}
+			return
+		}
+		resourceVersion, err = c.forward(ctx, request.Id, watcher, resourceVersion)
+		watcher.Stop()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.sendWatchError(request.Id, resourceVersion, err)
+			return
+		}
+	}
+}
+
+// forward 将watch事件推送给客户端，返回最后一个事件的resourceVersion
+// watch结果通道关闭时返回nil错误，由调用方重新watch
+func (c *watchConn) forward(ctx context.Context, id string, watcher watch.Interface, resourceVersion string) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion, nil
+			}
+			if event.Type == watch.Error {
+				return resourceVersion, apierrors.FromObject(event.Object)
+			}
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			resourceVersion = obj.GetResourceVersion()
+			watchEvent := &WatchEvent{Id: id, Type: string(event.Type), ResourceVersion: resourceVersion}
+			if event.Type != watch.Bookmark {
+				obj.SetManagedFields(nil)
+				watchEvent.Object = obj.Object
+			}
+			if err := c.send(watchEvent); err != nil {
+				// 写入失败说明连接已断开，关闭连接并取消所有订阅
+				c.close()
+				return resourceVersion, nil
+			}
+		}
+	}
+}
+
+// sendWatchError 推送watch错误，resourceVersion过期(410)时提示客户端重新获取列表
+func (c *watchConn) sendWatchError(id, resourceVersion string, err error) {
+	code := http.StatusInternalServerError
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Code != 0 {
+		code = int(status.Status().Code)
+	}
+	message := "watch失败,错误信息 " + err.Error()
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+		code = http.StatusGone
+		message = "resourceVersion " + resourceVersion + "已过期,请重新获取列表后订阅"
+	}
+	logger.Error(errors.New("watch " + id + "失败,错误信息 " + err.Error()))
+	c.send(&WatchEvent{Id: id, Type: WatchError, ResourceVersion: resourceVersion, Code: code, Message: message})
+}
+
+// sendError 推送订阅参数或权限错误
+func (c *watchConn) sendError(id string, code int, message string) {
+	c.send(&WatchEvent{Id: id, Type: WatchError, Code: code, Message: message})
+}
+
+// send 向客户端推送事件
+func (c *watchConn) send(event *WatchEvent) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(config.WatchWriteWait))
+	if err := c.conn.WriteJSON(event); err != nil {
+		logger.Info("watch write message err: %v", err)
+		return err
+	}
+	return nil
+}
+
+// heartbeat 定时向客户端发送websocket ping帧，直到连接断开
+func (c *watchConn) heartbeat() {
+	ticker := time.NewTicker(config.WatchHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// WriteControl可以与其他写方法并发调用
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WatchWriteWait)); err != nil {
+				c.close()
+				return
+			}
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// close 关闭连接并取消所有订阅，可以重复调用
+func (c *watchConn) close() {
+	c.cancel()
+	c.conn.Close()
+}